@@ -3,6 +3,7 @@ module github.com/Skufu/RSS
 go 1.23.2
 
 require (
-	github.com/google/uuid v1.6.0 
-	github.com/lib/pq v1.10.9 
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.43.0
 )
@@ -1,14 +1,21 @@
 package app
 
 import (
+	"database/sql"
+
 	"github.com/Skufu/RSS/internal/config"
 	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/feed"
+	"github.com/Skufu/RSS/internal/secret"
 )
 
 // State holds application state that can be passed to command handlers
 type State struct {
-	Db  *database.Queries
-	Cfg *config.Config
+	Db        *database.Queries
+	Conn      *sql.DB // the connection pool behind Db, for transactions
+	Cfg       *config.Config
+	Fetcher   *feed.Fetcher // shared HTTP client for feeds, pages and downloads
+	SecretKey *secret.Key   // nil when no secret_key is configured
 }
 
 // Command represents a CLI command with its name and arguments
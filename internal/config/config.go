@@ -10,10 +10,44 @@ import (
 type Config struct {
 	CurrentUserName string `json:"current_user_name"`
 	DatabaseURL     string `json:"database_url"`
+	MaxFeedFailures int    `json:"max_feed_failures"`
+
+	// Bounds of the adaptive per-feed refresh interval, as Go durations (e.g. "15m", "24h")
+	MinFetchInterval string `json:"min_fetch_interval"`
+	MaxFetchInterval string `json:"max_fetch_interval"`
+
+	// Directory podcast episodes are downloaded into, one subdirectory per feed
+	DownloadDir string `json:"download_dir"`
+
+	// HTTP client settings shared by every request
+	FetchTimeout        string `json:"fetch_timeout"` // Go duration, e.g. "10s"
+	UserAgent           string `json:"user_agent"`
+	MaxBodySize         int64  `json:"max_body_size"` // bytes
+	Proxy               string `json:"proxy"`         // http://, https:// or socks5:// URL, empty to use HTTP_PROXY/HTTPS_PROXY
+	CABundle            string `json:"ca_bundle"`     // PEM file with extra certificate authorities
+	MaxIdleConns        int    `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host"`
+	IdleConnTimeout     string `json:"idle_conn_timeout"` // Go duration
+	DisableKeepAlives   bool   `json:"disable_keep_alives"`
+
+	// Base64 encoded 32-byte key used to encrypt per-feed credentials stored in the database
+	SecretKey string `json:"secret_key"`
 }
 
 const configFileName = ".gatorconfig.json"
 
+// Default values for optional settings
+const (
+	defaultMaxFeedFailures  = 10 // consecutive failed fetches after which a feed is disabled
+	defaultMinFetchInterval = "10m"
+	defaultMaxFetchInterval = "24h"
+	defaultDownloadDir      = "Podcasts" // relative to the home directory
+	defaultFetchTimeout     = "10s"
+	defaultUserAgent        = "gator"
+	defaultMaxBodySize      = 10 << 20 // 10 MiB
+	defaultIdleConnTimeout  = "90s"
+)
+
 // Read reads the config file from ~/.gatorconfig.json and returns a Config struct
 func Read() (Config, error) {
 	configPath, err := getConfigFilePath()
@@ -39,6 +73,30 @@ func Read() (Config, error) {
 	if config.DatabaseURL == "" {
 		config.DatabaseURL = getDefaultDBURL()
 	}
+	if config.MaxFeedFailures <= 0 {
+		config.MaxFeedFailures = defaultMaxFeedFailures
+	}
+	if config.MinFetchInterval == "" {
+		config.MinFetchInterval = defaultMinFetchInterval
+	}
+	if config.MaxFetchInterval == "" {
+		config.MaxFetchInterval = defaultMaxFetchInterval
+	}
+	if config.DownloadDir == "" {
+		config.DownloadDir = getDefaultDownloadDir()
+	}
+	if config.FetchTimeout == "" {
+		config.FetchTimeout = defaultFetchTimeout
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = defaultUserAgent
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxBodySize
+	}
+	if config.IdleConnTimeout == "" {
+		config.IdleConnTimeout = defaultIdleConnTimeout
+	}
 
 	return config, nil
 }
@@ -46,8 +104,25 @@ func Read() (Config, error) {
 // getDefaultConfig returns a config with default values
 func getDefaultConfig() Config {
 	return Config{
-		DatabaseURL: getDefaultDBURL(),
+		DatabaseURL:      getDefaultDBURL(),
+		MaxFeedFailures:  defaultMaxFeedFailures,
+		MinFetchInterval: defaultMinFetchInterval,
+		MaxFetchInterval: defaultMaxFetchInterval,
+		DownloadDir:      getDefaultDownloadDir(),
+		FetchTimeout:     defaultFetchTimeout,
+		UserAgent:        defaultUserAgent,
+		MaxBodySize:      defaultMaxBodySize,
+		IdleConnTimeout:  defaultIdleConnTimeout,
+	}
+}
+
+// getDefaultDownloadDir returns the default download directory under the user's home directory
+func getDefaultDownloadDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return defaultDownloadDir
 	}
+	return filepath.Join(homeDir, defaultDownloadDir)
 }
 
 // getDefaultDBURL returns the default database URL from environment variable or a minimal default
@@ -7,9 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
@@ -22,10 +24,10 @@ WITH inserted_feed_follow AS (
         $4,
         $5
     )
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, keep_episodes
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.keep_episodes,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -42,13 +44,14 @@ type CreateFeedFollowParams struct {
 }
 
 type CreateFeedFollowRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	KeepEpisodes sql.NullInt32
+	FeedName     string
+	UserName     string
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
@@ -66,6 +69,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.KeepEpisodes,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -74,29 +78,37 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 
 const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows ff
-WHERE ff.user_id = $1 AND ff.feed_id = (
-    SELECT id FROM feeds WHERE url = $2
-)
+WHERE ff.user_id = $1 AND ff.feed_id = $2
 `
 
 type DeleteFeedFollowParams struct {
 	UserID uuid.UUID
-	Url    string
+	FeedID uuid.UUID
 }
 
 func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
+	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.FeedID)
 	return err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, next_fetch_at, disabled_at, last_status_code, fetch_interval_seconds, skip_hours, skip_days, title, site_url, description, language, image_url, generator, canonical_url, last_error_kind, proxy_url, request_headers, resave_posts FROM feeds
+WHERE canonical_url = $1::text
+   OR (canonical_url IS NULL AND url = $2::text)
+   OR id IN (SELECT feed_id FROM feed_url_history WHERE feed_url_history.canonical_url = $1::text)
+ORDER BY created_at
 LIMIT 1
 `
 
-func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+type GetFeedByURLParams struct {
+	CanonicalUrl string
+	Url          string
+}
+
+// Feeds added before URLs were canonicalized only match their exact URL.
+// Feeds that moved are also found by their previous URLs.
+func (q *Queries) GetFeedByURL(ctx context.Context, arg GetFeedByURLParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, arg.CanonicalUrl, arg.Url)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -106,6 +118,30 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.LastStatusCode,
+		&i.FetchIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.CanonicalUrl,
+		&i.LastErrorKind,
+		&i.ProxyUrl,
+		&i.RequestHeaders,
+		&i.ResavePosts,
 	)
 	return i, err
 }
@@ -166,3 +202,21 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const setFeedFollowKeepEpisodes = `-- name: SetFeedFollowKeepEpisodes :exec
+UPDATE feed_follows
+SET keep_episodes = $3,
+    updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type SetFeedFollowKeepEpisodesParams struct {
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	KeepEpisodes sql.NullInt32
+}
+
+func (q *Queries) SetFeedFollowKeepEpisodes(ctx context.Context, arg SetFeedFollowKeepEpisodesParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowKeepEpisodes, arg.UserID, arg.FeedID, arg.KeepEpisodes)
+	return err
+}
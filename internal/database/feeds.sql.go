@@ -7,31 +7,128 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET lease_owner = $1::text,
+    lease_expires_at = NOW() + make_interval(secs => $2::int)
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE (lease_expires_at IS NULL OR lease_expires_at < NOW())
+      AND disabled_at IS NULL
+      AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY next_fetch_at NULLS FIRST, last_fetched_at NULLS FIRST
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, next_fetch_at, disabled_at, last_status_code, fetch_interval_seconds, skip_hours, skip_days, title, site_url, description, language, image_url, generator, canonical_url, last_error_kind, proxy_url, request_headers, resave_posts
+`
+
+type ClaimFeedsToFetchParams struct {
+	LeaseOwner   string
+	LeaseSeconds int32
+	BatchSize    int32
+}
+
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.LeaseOwner, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.LastStatusCode,
+			&i.FetchIntervalSeconds,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.Title,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.CanonicalUrl,
+			&i.LastErrorKind,
+			&i.ProxyUrl,
+			&i.RequestHeaders,
+			&i.ResavePosts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const clearFeedResavePosts = `-- name: ClearFeedResavePosts :exec
+UPDATE feeds
+SET resave_posts = FALSE
+WHERE id = $1
+`
+
+func (q *Queries) ClearFeedResavePosts(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, clearFeedResavePosts, id)
+	return err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, canonical_url, proxy_url, request_headers)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, next_fetch_at, disabled_at, last_status_code, fetch_interval_seconds, skip_hours, skip_days, title, site_url, description, language, image_url, generator, canonical_url, last_error_kind, proxy_url, request_headers, resave_posts
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	Url            string
+	UserID         uuid.UUID
+	CanonicalUrl   sql.NullString
+	ProxyUrl       sql.NullString
+	RequestHeaders []byte
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -42,6 +139,9 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.CanonicalUrl,
+		arg.ProxyUrl,
+		arg.RequestHeaders,
 	)
 	var i Feed
 	err := row.Scan(
@@ -52,22 +152,162 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.LastStatusCode,
+		&i.FetchIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.Title,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.CanonicalUrl,
+		&i.LastErrorKind,
+		&i.ProxyUrl,
+		&i.RequestHeaders,
+		&i.ResavePosts,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const enableFeed = `-- name: EnableFeed :exec
+UPDATE feeds
+SET consecutive_failures = 0,
+    next_fetch_at = NULL,
+    disabled_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enableFeed, id)
+	return err
+}
+
+const getFeedHealth = `-- name: GetFeedHealth :many
+SELECT
+    f.id,
+    f.name,
+    f.url,
+    f.created_at,
+    f.last_fetched_at,
+    f.last_success_at,
+    f.last_error,
+    f.last_error_kind,
+    f.last_status_code,
+    f.consecutive_failures,
+    f.disabled_at,
+    COUNT(p.id) AS item_count,
+    (COUNT(p.id) / GREATEST(EXTRACT(EPOCH FROM NOW() - f.created_at) / 86400, 1))::float8 AS items_per_day
+FROM feeds f
+LEFT JOIN posts p ON p.feed_id = f.id
+GROUP BY f.id
+ORDER BY f.name
+`
+
+type GetFeedHealthRow struct {
+	ID                  uuid.UUID
+	Name                string
+	Url                 string
+	CreatedAt           time.Time
+	LastFetchedAt       sql.NullTime
+	LastSuccessAt       sql.NullTime
+	LastError           sql.NullString
+	LastErrorKind       sql.NullString
+	LastStatusCode      sql.NullInt32
+	ConsecutiveFailures int32
+	DisabledAt          sql.NullTime
+	ItemCount           int64
+	ItemsPerDay         float64
+}
+
+func (q *Queries) GetFeedHealth(ctx context.Context) ([]GetFeedHealthRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedHealth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedHealthRow
+	for rows.Next() {
+		var i GetFeedHealthRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.CreatedAt,
+			&i.LastFetchedAt,
+			&i.LastSuccessAt,
+			&i.LastError,
+			&i.LastErrorKind,
+			&i.LastStatusCode,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+			&i.ItemCount,
+			&i.ItemsPerDay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeeds = `-- name: GetFeeds :many
-SELECT f.id, f.name, f.url, u.name as user_name
+SELECT
+    f.id,
+    f.name,
+    f.url,
+    f.title,
+    f.site_url,
+    f.description,
+    f.language,
+    f.image_url,
+    f.generator,
+    f.last_success_at,
+    u.name as user_name
 FROM feeds f
 JOIN users u ON f.user_id = u.id
 ORDER BY f.name
 `
 
 type GetFeedsRow struct {
-	ID       uuid.UUID
-	Name     string
-	Url      string
-	UserName string
+	ID            uuid.UUID
+	Name          string
+	Url           string
+	Title         sql.NullString
+	SiteUrl       sql.NullString
+	Description   sql.NullString
+	Language      sql.NullString
+	ImageUrl      sql.NullString
+	Generator     sql.NullString
+	LastSuccessAt sql.NullTime
+	UserName      string
 }
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
@@ -83,6 +323,13 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 			&i.ID,
 			&i.Name,
 			&i.Url,
+			&i.Title,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.LastSuccessAt,
 			&i.UserName,
 		); err != nil {
 			return nil, err
@@ -98,26 +345,110 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeedURLs = `-- name: GetFeedURLs :many
+SELECT id, name, url, canonical_url
 FROM feeds
-ORDER BY last_fetched_at NULLS FIRST
-LIMIT 1
+ORDER BY created_at, id
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
+type GetFeedURLsRow struct {
+	ID           uuid.UUID
+	Name         string
+	Url          string
+	CanonicalUrl sql.NullString
+}
+
+func (q *Queries) GetFeedURLs(ctx context.Context) ([]GetFeedURLsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedURLs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedURLsRow
+	for rows.Next() {
+		var i GetFeedURLsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.CanonicalUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markFeedFetchFailed = `-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET consecutive_failures = $1,
+    last_error = $2,
+    last_status_code = $3,
+    next_fetch_at = NOW() + make_interval(secs => $4::int),
+    disabled_at = CASE WHEN $5::bool THEN NOW() END,
+    last_error_kind = $6,
+    updated_at = NOW()
+WHERE id = $7
+`
+
+type MarkFeedFetchFailedParams struct {
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastStatusCode      sql.NullInt32
+	NextFetchInSeconds  int32
+	Disabled            bool
+	LastErrorKind       sql.NullString
+	ID                  uuid.UUID
+}
+
+func (q *Queries) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchFailed,
+		arg.ConsecutiveFailures,
+		arg.LastError,
+		arg.LastStatusCode,
+		arg.NextFetchInSeconds,
+		arg.Disabled,
+		arg.LastErrorKind,
+		arg.ID,
 	)
-	return i, err
+	return err
+}
+
+const markFeedFetchSucceeded = `-- name: MarkFeedFetchSucceeded :exec
+UPDATE feeds
+SET consecutive_failures = 0,
+    last_error = NULL,
+    last_error_kind = NULL,
+    last_status_code = $1,
+    last_success_at = NOW(),
+    next_fetch_at = NOW() + make_interval(secs => $2::int),
+    fetch_interval_seconds = $3,
+    updated_at = NOW()
+WHERE id = $4
+`
+
+type MarkFeedFetchSucceededParams struct {
+	LastStatusCode       sql.NullInt32
+	NextFetchInSeconds   int32
+	FetchIntervalSeconds sql.NullInt32
+	ID                   uuid.UUID
+}
+
+func (q *Queries) MarkFeedFetchSucceeded(ctx context.Context, arg MarkFeedFetchSucceededParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchSucceeded,
+		arg.LastStatusCode,
+		arg.NextFetchInSeconds,
+		arg.FetchIntervalSeconds,
+		arg.ID,
+	)
+	return err
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :exec
@@ -131,3 +462,220 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = $1,
+    updated_at = NOW()
+WHERE feed_id = $2
+  AND user_id NOT IN (SELECT user_id FROM feed_follows WHERE feed_id = $1)
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// Moves follows to another feed, skipping users who already follow it
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const moveFeedPosts = `-- name: MoveFeedPosts :exec
+UPDATE posts
+SET feed_id = $1
+WHERE feed_id = $2
+  AND guid NOT IN (SELECT guid FROM posts WHERE feed_id = $1)
+`
+
+type MoveFeedPostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// Moves posts to another feed, skipping posts it already has
+func (q *Queries) MoveFeedPosts(ctx context.Context, arg MoveFeedPostsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedPosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const releaseFeedLease = `-- name: ReleaseFeedLease :exec
+UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2::text
+`
+
+type ReleaseFeedLeaseParams struct {
+	ID         uuid.UUID
+	LeaseOwner string
+}
+
+func (q *Queries) ReleaseFeedLease(ctx context.Context, arg ReleaseFeedLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedLease, arg.ID, arg.LeaseOwner)
+	return err
+}
+
+const renewFeedLease = `-- name: RenewFeedLease :execrows
+UPDATE feeds
+SET lease_expires_at = NOW() + make_interval(secs => $1::int)
+WHERE id = $2 AND lease_owner = $3::text
+`
+
+type RenewFeedLeaseParams struct {
+	LeaseSeconds int32
+	ID           uuid.UUID
+	LeaseOwner   string
+}
+
+// Extends a lease the aggregator still holds; no rows means another aggregator took the feed
+func (q *Queries) RenewFeedLease(ctx context.Context, arg RenewFeedLeaseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renewFeedLease, arg.LeaseSeconds, arg.ID, arg.LeaseOwner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedCanonicalURL = `-- name: SetFeedCanonicalURL :execrows
+UPDATE feeds
+SET canonical_url = $1::text,
+    updated_at = NOW()
+WHERE id = $2
+  AND canonical_url IS NULL
+  AND NOT EXISTS (SELECT 1 FROM feeds other WHERE other.canonical_url = $1::text)
+`
+
+type SetFeedCanonicalURLParams struct {
+	CanonicalUrl string
+	ID           uuid.UUID
+}
+
+// Fills in the canonical URL of a feed added before URLs were canonicalized,
+// unless another feed already has it; those are duplicates left for mergefeeds
+func (q *Queries) SetFeedCanonicalURL(ctx context.Context, arg SetFeedCanonicalURLParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedCanonicalURL, arg.CanonicalUrl, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeedCacheHeaders = `-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedCacheHeadersParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheHeaders(ctx context.Context, arg UpdateFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeaders, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+    site_url = $3,
+    description = $4,
+    language = $5,
+    image_url = $6,
+    generator = $7,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	Title       sql.NullString
+	SiteUrl     sql.NullString
+	Description sql.NullString
+	Language    sql.NullString
+	ImageUrl    sql.NullString
+	Generator   sql.NullString
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.SiteUrl,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+		arg.Generator,
+	)
+	return err
+}
+
+const updateFeedSettings = `-- name: UpdateFeedSettings :exec
+UPDATE feeds
+SET name = $2,
+    proxy_url = $3,
+    request_headers = $4,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedSettingsParams struct {
+	ID             uuid.UUID
+	Name           string
+	ProxyUrl       sql.NullString
+	RequestHeaders []byte
+}
+
+func (q *Queries) UpdateFeedSettings(ctx context.Context, arg UpdateFeedSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedSettings,
+		arg.ID,
+		arg.Name,
+		arg.ProxyUrl,
+		arg.RequestHeaders,
+	)
+	return err
+}
+
+const updateFeedSkipSchedule = `-- name: UpdateFeedSkipSchedule :exec
+UPDATE feeds
+SET skip_hours = $2,
+    skip_days = $3,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedSkipScheduleParams struct {
+	ID        uuid.UUID
+	SkipHours []int32
+	SkipDays  []string
+}
+
+func (q *Queries) UpdateFeedSkipSchedule(ctx context.Context, arg UpdateFeedSkipScheduleParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedSkipSchedule, arg.ID, pq.Array(arg.SkipHours), pq.Array(arg.SkipDays))
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2,
+    canonical_url = $3,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedURLParams struct {
+	ID           uuid.UUID
+	Url          string
+	CanonicalUrl sql.NullString
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url, arg.CanonicalUrl)
+	return err
+}
@@ -11,22 +11,93 @@ import (
 	"github.com/google/uuid"
 )
 
-type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+type Author struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
 }
 
-type FeedFollow struct {
+type Category struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
+	Name      string
+}
+
+type Download struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	UserID          uuid.UUID
+	EnclosureID     uuid.UUID
+	Status          string
+	FilePath        sql.NullString
+	BytesDownloaded int64
+	TotalBytes      sql.NullInt64
+	LastError       sql.NullString
+	Etag            sql.NullString
+	LastModified    sql.NullString
+}
+
+type Enclosure struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PostID          uuid.UUID
+	Url             string
+	MimeType        sql.NullString
+	Length          sql.NullInt64
+	DurationSeconds sql.NullInt32
+}
+
+type Feed struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	LeaseOwner           sql.NullString
+	LeaseExpiresAt       sql.NullTime
+	ConsecutiveFailures  int32
+	LastError            sql.NullString
+	LastSuccessAt        sql.NullTime
+	NextFetchAt          sql.NullTime
+	DisabledAt           sql.NullTime
+	LastStatusCode       sql.NullInt32
+	FetchIntervalSeconds sql.NullInt32
+	SkipHours            []int32
+	SkipDays             []string
+	Title                sql.NullString
+	SiteUrl              sql.NullString
+	Description          sql.NullString
+	Language             sql.NullString
+	ImageUrl             sql.NullString
+	Generator            sql.NullString
+	CanonicalUrl         sql.NullString
+	LastErrorKind        sql.NullString
+	ProxyUrl             sql.NullString
+	RequestHeaders       []byte
+	ResavePosts          bool
+}
+
+type FeedFollow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	KeepEpisodes sql.NullInt32
+}
+
+type FeedUrlHistory struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	FeedID       uuid.UUID
+	Url          string
+	CanonicalUrl string
 }
 
 type Post struct {
@@ -38,6 +109,30 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	ContentHash string
+	Revision    int32
+	Content     sql.NullString
+	Episode     sql.NullInt32
+}
+
+type PostAuthor struct {
+	PostID   uuid.UUID
+	AuthorID uuid.UUID
+	Position int32
+}
+
+type PostCategory struct {
+	PostID     uuid.UUID
+	CategoryID uuid.UUID
+	Position   int32
+}
+
+type PostView struct {
+	UserID       uuid.UUID
+	PostID       uuid.UUID
+	SeenRevision int32
+	SeenAt       time.Time
 }
 
 type User struct {
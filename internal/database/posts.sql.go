@@ -13,8 +13,32 @@ import (
 	"github.com/google/uuid"
 )
 
+const adoptMigratedPost = `-- name: AdoptMigratedPost :exec
+UPDATE posts
+SET guid = $1
+WHERE feed_id = $2
+  AND url = $3
+  AND guid = 'sha256:' || encode(sha256(convert_to(url || E'\n' || title, 'UTF8')), 'hex')
+  AND guid <> $1
+  AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.feed_id = $2 AND p.guid = $1)
+`
+
+type AdoptMigratedPostParams struct {
+	Guid   string
+	FeedID uuid.UUID
+	Url    string
+}
+
+// Gives a post stored before guids were tracked the guid of the item it came from, so the item
+// updates it instead of being stored again. Such posts carry the fallback guid of their own link
+// and title (see 012_post_guids).
+func (q *Queries) AdoptMigratedPost(ctx context.Context, arg AdoptMigratedPostParams) error {
+	_, err := q.db.ExecContext(ctx, adoptMigratedPost, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content, episode)
 VALUES (
     $1,
     $2,
@@ -23,9 +47,31 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    episode = EXCLUDED.episode,
+    published_at = EXCLUDED.published_at,
+    content_hash = EXCLUDED.content_hash,
+    -- Filling in content for a post stored before it was kept is not an edit
+    revision = CASE
+        WHEN posts.content IS NULL
+            AND posts.title = EXCLUDED.title
+            AND posts.description IS NOT DISTINCT FROM EXCLUDED.description
+        THEN posts.revision
+        ELSE posts.revision + 1
+    END,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, revision
 `
 
 type CreatePostParams struct {
@@ -37,9 +83,18 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	ContentHash string
+	Content     sql.NullString
+	Episode     sql.NullInt32
+}
+
+type CreatePostRow struct {
+	ID       uuid.UUID
+	Revision int32
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreatePostRow, error) {
 	row := q.db.QueryRowContext(ctx, createPost,
 		arg.ID,
 		arg.CreatedAt,
@@ -49,8 +104,59 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Guid,
+		arg.ContentHash,
+		arg.Content,
+		arg.Episode,
 	)
-	var i Post
+	var i CreatePostRow
+	err := row.Scan(&i.ID, &i.Revision)
+	return i, err
+}
+
+const getPostForUser = `-- name: GetPostForUser :one
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.content,
+    p.published_at,
+    p.feed_id,
+    p.revision,
+    f.name AS feed_name
+FROM posts p
+JOIN feeds f ON p.feed_id = f.id
+JOIN feed_follows ff ON f.id = ff.feed_id
+WHERE p.id = $1
+  AND ff.user_id = $2
+`
+
+type GetPostForUserParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+type GetPostForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	Content     sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Revision    int32
+	FeedName    string
+}
+
+// Gets a post from one of the feeds the user follows
+func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (GetPostForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUser, arg.ID, arg.UserID)
+	var i GetPostForUserRow
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
@@ -58,12 +164,33 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Title,
 		&i.Url,
 		&i.Description,
+		&i.Content,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Revision,
+		&i.FeedName,
 	)
 	return i, err
 }
 
+const getPostIDByGUID = `-- name: GetPostIDByGUID :one
+SELECT id
+FROM posts
+WHERE feed_id = $1 AND guid = $2
+`
+
+type GetPostIDByGUIDParams struct {
+	FeedID uuid.UUID
+	Guid   string
+}
+
+func (q *Queries) GetPostIDByGUID(ctx context.Context, arg GetPostIDByGUIDParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getPostIDByGUID, arg.FeedID, arg.Guid)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
 SELECT 
     p.id,
@@ -74,34 +201,60 @@ SELECT
     p.description,
     p.published_at,
     p.feed_id,
-    f.name AS feed_name
+    p.revision,
+    p.episode,
+    f.name AS feed_name,
+    pv.seen_revision
 FROM posts p
 JOIN feeds f ON p.feed_id = f.id
 JOIN feed_follows ff ON f.id = ff.feed_id
+LEFT JOIN post_views pv ON pv.post_id = p.id AND pv.user_id = ff.user_id
 WHERE ff.user_id = $1
+  AND ($2::text IS NULL OR EXISTS (
+      SELECT 1
+      FROM post_authors pa
+      JOIN authors a ON pa.author_id = a.id
+      WHERE pa.post_id = p.id AND LOWER(a.name) = LOWER($2::text)
+  ))
+  AND ($3::text IS NULL OR EXISTS (
+      SELECT 1
+      FROM post_categories pc
+      JOIN categories c ON pc.category_id = c.id
+      WHERE pc.post_id = p.id AND LOWER(c.name) = LOWER($3::text)
+  ))
 ORDER BY p.published_at DESC
-LIMIT $2
+LIMIT $4::int
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID   uuid.UUID
+	Author   sql.NullString
+	Category sql.NullString
+	Limit    int32
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Revision     int32
+	Episode      sql.NullInt32
+	FeedName     string
+	SeenRevision sql.NullInt32
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Author,
+		arg.Category,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +271,10 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Revision,
+			&i.Episode,
 			&i.FeedName,
+			&i.SeenRevision,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,186 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// partSuffix marks a file that is still being downloaded
+const partSuffix = ".part"
+
+// Progress reports how much of a file has been written so far
+type Progress struct {
+	BytesDownloaded int64
+	TotalBytes      int64 // zero when the server did not report a size
+
+	// Validators of the file, used to check it has not changed before resuming
+	ETag         string
+	LastModified string
+}
+
+// Fetch downloads fileURL to dest with the given client, resuming from dest.part when a previous
+// attempt was interrupted. prev is the progress recorded by that attempt: the partial file is only
+// resumed when prev carries a validator, which is sent as If-Range so a file that changed since is
+// downloaded again from the start. The partial file is renamed to dest once the whole body has been written.
+// A failed attempt returns the progress to record for the next one: the partial file and its validators
+// are kept unless the server answered with a different version of the file.
+func Fetch(ctx context.Context, client *http.Client, userAgent, fileURL, dest string, prev Progress) (Progress, error) {
+	partPath := dest + partSuffix
+
+	// Pick up where the last attempt stopped, if we can tell the file is still the same
+	var offset int64
+	validator := ifRange(prev)
+	if info, err := os.Stat(partPath); err == nil && validator != "" {
+		offset = info.Size()
+	}
+	// What is on disk when an attempt fails before writing anything
+	unchanged := Progress{BytesDownloaded: offset, TotalBytes: prev.TotalBytes, ETag: prev.ETag, LastModified: prev.LastModified}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return unchanged, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+
+	// The client should have no overall timeout: episodes can take a long time, cancellation comes from ctx
+	resp, err := client.Do(req)
+	if err != nil {
+		return unchanged, fmt.Errorf("error downloading file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var progress Progress
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// The server honoured the range, append to what we already have as long as it
+		// continues exactly where the partial file ends
+		if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			// Don't splice mismatched pieces together, ask for the range again next time
+			return unchanged, fmt.Errorf("server resumed at %q instead of byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		flags |= os.O_APPEND
+		// If-Range matched, so the validators still hold even when the response leaves them out
+		progress = unchanged
+		progress.TotalBytes = 0
+	case http.StatusOK:
+		// Full body, either a fresh download, a server that ignores ranges or a file that changed
+		flags |= os.O_TRUNC
+		offset = 0
+		progress = Progress{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file already holds the whole body
+		if offset > 0 {
+			if err := os.Rename(partPath, dest); err != nil {
+				return unchanged, fmt.Errorf("error finishing download: %w", err)
+			}
+			unchanged.TotalBytes = offset
+			return unchanged, nil
+		}
+		return unchanged, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	default:
+		return unchanged, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > 0 {
+		progress.TotalBytes = offset + resp.ContentLength
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		if resp.StatusCode == http.StatusOK {
+			// The partial file, if any, is from another version of the file: start over next time
+			return Progress{}, fmt.Errorf("error opening file: %w", err)
+		}
+		return unchanged, fmt.Errorf("error opening file: %w", err)
+	}
+
+	written, err := io.Copy(file, resp.Body)
+	progress.BytesDownloaded += written
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return progress, fmt.Errorf("error writing file: %w", err)
+	}
+	if progress.TotalBytes == 0 {
+		progress.TotalBytes = progress.BytesDownloaded
+	}
+
+	if err := os.Rename(partPath, dest); err != nil {
+		return progress, fmt.Errorf("error finishing download: %w", err)
+	}
+
+	return progress, nil
+}
+
+// ifRange picks the validator to send as If-Range: a strong ETag, or else the Last-Modified date.
+// Weak ETags are not allowed in If-Range, so they give no validator.
+func ifRange(p Progress) string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// rangeStart returns the first byte position of a Content-Range header such as "bytes 100-199/200"
+func rangeStart(contentRange string) (int64, bool) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(contentRange), "bytes ")
+	if !ok {
+		return 0, false
+	}
+	first, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return start, true
+}
+
+// FileName derives a file name for an episode from the last path segment of fileURL, prefixed
+// with id so that episodes served under the same name (media.mp3?id=1, download, ...) get distinct files
+func FileName(id, fileURL string) string {
+	name := ""
+	if u, err := url.Parse(fileURL); err == nil {
+		name = path.Base(u.Path)
+	}
+	if name == "" || name == "." || name == "/" {
+		return SafeName(id)
+	}
+	return SafeName(id + "-" + name)
+}
+
+// SafeName replaces characters that are awkward in file and directory names
+func SafeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,163 @@
+package feed
+
+import "strings"
+
+// atomFeed represents the structure of an Atom 1.0 feed
+type atomFeed struct {
+	Title     atomText      `xml:"title"`
+	Subtitle  atomText      `xml:"subtitle"`
+	Links     []atomLink    `xml:"link"`
+	Lang      string        `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Icon      string        `xml:"icon"`
+	Logo      string        `xml:"logo"`
+	Generator atomGenerator `xml:"generator"`
+	Authors   []atomPerson  `xml:"author"` // apply to entries without their own authors
+	Entries   []atomEntry   `xml:"entry"`
+}
+
+// atomEntry represents an entry in an Atom feed
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// atomGenerator represents the Atom <generator> element naming the software that produced the feed
+type atomGenerator struct {
+	Name    string `xml:",chardata"`
+	Version string `xml:"version,attr"`
+}
+
+// String returns the generator name followed by its version, if any
+func (g atomGenerator) String() string {
+	name := strings.TrimSpace(g.Name)
+	if name != "" && g.Version != "" {
+		return name + " " + strings.TrimSpace(g.Version)
+	}
+	return name
+}
+
+// atomPerson represents an Atom person construct such as <author>
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+// atomCategory represents an Atom <category> element
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"` // human-readable form of the term, if any
+}
+
+// atomLink represents an Atom <link> element
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// atomText represents an Atom text construct, which may hold plain text, escaped HTML or inline XHTML
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Body  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// String returns the text content, keeping inline XHTML markup as-is
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Body)
+}
+
+// alternateLink returns the href of the rel="alternate" link, falling back to the first link
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		// A link without a rel attribute is an alternate link per RFC 4287
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// toRSSFeed maps an Atom feed onto the RSSFeed model used by the aggregator
+func (a *atomFeed) toRSSFeed() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = a.Title.String()
+	feed.Channel.Link = alternateLink(a.Links)
+	feed.Channel.Description = a.Subtitle.String()
+	feed.Channel.Language = strings.TrimSpace(a.Lang)
+	feed.Channel.Generator = a.Generator.String()
+
+	// Prefer the larger logo over the icon
+	feed.Channel.Image.URL = strings.TrimSpace(a.Logo)
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = strings.TrimSpace(a.Icon)
+	}
+
+	for _, entry := range a.Entries {
+		// Prefer the summary, falling back to the full content
+		description := entry.Summary.String()
+		if description == "" {
+			description = entry.Content.String()
+		}
+
+		// Prefer the original publication date, falling back to the last update
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+
+		item := RSSItem{
+			Title:       entry.Title.String(),
+			Link:        alternateLink(entry.Links),
+			Description: description,
+			Content:     entry.Content.String(),
+			PubDate:     strings.TrimSpace(pubDate),
+			GUID:        strings.TrimSpace(entry.ID),
+		}
+
+		authors := entry.Authors
+		if len(authors) == 0 {
+			authors = a.Authors
+		}
+		for _, author := range authors {
+			item.Authors = append(item.Authors, author.Name)
+		}
+
+		for _, category := range entry.Categories {
+			if category.Label != "" {
+				item.Categories = append(item.Categories, category.Label)
+			} else {
+				item.Categories = append(item.Categories, category.Term)
+			}
+		}
+
+		// Atom attaches media files as rel="enclosure" links
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" && link.Href != "" {
+				item.Enclosures = append(item.Enclosures, RSSEnclosure{
+					URL:    link.Href,
+					Type:   link.Type,
+					Length: link.Length,
+				})
+			}
+		}
+
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return &feed
+}
@@ -0,0 +1,54 @@
+package feed
+
+import (
+	"html"
+	"net/mail"
+	"slices"
+	"strings"
+)
+
+// AuthorNames returns the item's authors from <author> and <dc:creator> without duplicates.
+// RSS 2.0 authors written as an email address keep only the display name when one is given.
+func (item *RSSItem) AuthorNames() []string {
+	var names []string
+	for _, author := range slices.Concat(item.Authors, item.Creators) {
+		names = append(names, authorName(author))
+	}
+	return uniqueNames(names)
+}
+
+// CategoryNames returns the item's categories without duplicates
+func (item *RSSItem) CategoryNames() []string {
+	return uniqueNames(item.Categories)
+}
+
+// authorName extracts the display name from RSS 2.0 values such as
+// "jane@example.com (Jane Doe)" or "Jane Doe <jane@example.com>"
+func authorName(author string) string {
+	author = strings.TrimSpace(author)
+	if open := strings.LastIndex(author, "("); open > 0 && strings.HasSuffix(author, ")") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	if addr, err := mail.ParseAddress(author); err == nil && addr.Name != "" {
+		return addr.Name
+	}
+	return author
+}
+
+// uniqueNames trims and unescapes names, dropping empty ones and case-insensitive duplicates
+func uniqueNames(values []string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		name := strings.TrimSpace(html.UnescapeString(value))
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package feed
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters added by campaign and click tracking, which never change the feed
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+}
+
+// defaultPorts maps schemes to the port they use when none is given
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL cleans up a feed URL for storage: the scheme and host are lowercased, default ports,
+// fragments and tracking parameters are dropped and the remaining query parameters are sorted.
+// URLs that cannot be parsed are returned trimmed but otherwise unchanged.
+func NormalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if host, port, err := net.SplitHostPort(u.Host); err == nil && defaultPorts[u.Scheme] == port {
+		u.Host = host
+		if strings.Contains(host, ":") {
+			// Keep the brackets around IPv6 addresses
+			u.Host = "[" + host + "]"
+		}
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_") {
+				query.Del(name)
+			}
+		}
+		// Encode sorts the parameters by name
+		u.RawQuery = query.Encode()
+	}
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+// CanonicalURL returns the key that identifies a feed regardless of how its URL is written.
+// On top of NormalizeURL it ignores the scheme and any trailing slash, so
+// "http://Example.com/feed/" and "https://example.com/feed?utm_source=x" give the same key.
+func CanonicalURL(rawURL string) string {
+	normalized := NormalizeURL(rawURL)
+	u, err := url.Parse(normalized)
+	if err != nil || u.Host == "" {
+		return normalized
+	}
+
+	key := u.Host + strings.TrimRight(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
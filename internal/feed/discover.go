@@ -0,0 +1,153 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// feedMediaTypes are the <link type> values that identify a feed
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+// commonFeedPaths are tried when a page does not advertise its feeds
+var commonFeedPaths = []string{"/feed", "/rss.xml", "/feed.xml", "/atom.xml", "/index.xml", "/rss"}
+
+// DiscoveredFeed is a feed found for a web page
+type DiscoveredFeed struct {
+	URL   string
+	Title string // from the <link> element or the feed itself, may be empty
+	// Feed is the parsed feed when discovery already fetched it, nil for feeds only linked from a page
+	Feed *RSSFeed
+}
+
+// Discover finds the feeds behind a URL. A URL that already points at a feed is returned as is;
+// for an HTML page the feeds it advertises with <link rel="alternate"> are returned, falling back
+// to probing common feed paths on the same site. The proxy and headers in opts are used for every request.
+func (f *Fetcher) Discover(ctx context.Context, pageURL string, opts FetchOptions) ([]DiscoveredFeed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.opts.UserAgent)
+	setHeaders(req, opts.Headers)
+
+	redirects := redirectChain{headers: opts.Headers}
+	client, err := f.client(opts.Proxy, redirects.check)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := f.readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The URL may already be a feed
+	if feed, err := parseFeed(bytes.NewReader(body), resp.Header.Get("Content-Type")); err == nil {
+		return []DiscoveredFeed{{URL: pageURL, Title: strings.TrimSpace(feed.Channel.Title), Feed: feed}}, nil
+	}
+
+	// Relative links are resolved against the final URL after redirects
+	base := resp.Request.URL
+
+	if feeds := alternateFeeds(string(body), base); len(feeds) > 0 {
+		return feeds, nil
+	}
+
+	return f.probeFeedPaths(ctx, base, opts), nil
+}
+
+// alternateFeeds returns the feeds advertised by <link rel="alternate"> elements in an HTML page
+func alternateFeeds(page string, base *url.URL) []DiscoveredFeed {
+	var feeds []DiscoveredFeed
+	seen := make(map[string]bool)
+	z := html.NewTokenizer(strings.NewReader(page))
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+		token := z.Token()
+		// Feeds are only advertised in the head
+		if tokenType == html.StartTagToken && token.Data == "body" {
+			break
+		}
+		if token.Data != "link" || !hasToken(attr(token, "rel"), "alternate") {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(attr(token, "type"))
+		if err != nil || !feedMediaTypes[mediaType] {
+			continue
+		}
+
+		ref, err := url.Parse(strings.TrimSpace(attr(token, "href")))
+		if err != nil || ref.String() == "" {
+			continue
+		}
+		feedURL := base.ResolveReference(ref).String()
+		if seen[feedURL] {
+			continue
+		}
+		seen[feedURL] = true
+		feeds = append(feeds, DiscoveredFeed{URL: feedURL, Title: strings.TrimSpace(attr(token, "title"))})
+	}
+	return feeds
+}
+
+// probeFeedPaths fetches the common feed paths of a site and returns the ones that parse as feeds
+func (f *Fetcher) probeFeedPaths(ctx context.Context, base *url.URL, opts FetchOptions) []DiscoveredFeed {
+	var feeds []DiscoveredFeed
+	for _, path := range commonFeedPaths {
+		feedURL := base.ResolveReference(&url.URL{Path: path}).String()
+		result, err := f.Fetch(ctx, feedURL, FetchOptions{Proxy: opts.Proxy, Headers: opts.Headers})
+		if err != nil {
+			continue
+		}
+		feeds = append(feeds, DiscoveredFeed{URL: feedURL, Title: strings.TrimSpace(result.Feed.Channel.Title), Feed: result.Feed})
+	}
+	return feeds
+}
+
+// attr returns the value of a tag attribute, or "" when it is missing
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken reports whether a space-separated attribute value such as rel contains token
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
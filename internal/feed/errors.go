@@ -0,0 +1,93 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned by Fetcher.Fetch when the server answers with an unexpected HTTP status
+type StatusError struct {
+	StatusCode int
+
+	// RetryAfter is how long the server asked us to wait before trying again,
+	// from the Retry-After header of a 429 or 503 response; zero when not given
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("unexpected status code: %d (retry after %s)", e.StatusCode, e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Gone reports whether the publisher removed the feed for good (410 Gone)
+func (e *StatusError) Gone() bool {
+	return e.StatusCode == http.StatusGone
+}
+
+// Throttled reports whether the server asked us to slow down (429) or is temporarily unavailable (503)
+func (e *StatusError) Throttled() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable
+}
+
+// NetworkError is returned by Fetcher.Fetch when the feed could not be downloaded at all:
+// DNS failures, refused connections, timeouts and connections dropped mid-body.
+// These are usually transient and say little about the feed itself.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return "network error: " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned by Fetcher.Fetch when the server answered but the body is not a feed we can read
+type ParseError struct {
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// SizeError is returned by Fetcher.Fetch when the response is larger than the configured maximum
+// body size. The download is abandoned as soon as the limit is crossed.
+type SizeError struct {
+	Limit int64 // bytes
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("response is larger than the %d byte limit (max_body_size)", e.Limit)
+}
+
+// retryAfter parses a Retry-After header, given either as a number of seconds or as an HTTP date
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
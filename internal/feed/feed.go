@@ -1,35 +1,136 @@
 package feed
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+	"unicode"
 )
 
 // RSSFeed represents the structure of an RSS feed
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
+		Title string `xml:"title"`
+
+		// <atom:link> elements such as rel="self"; declared before Link so they are not mistaken for it
+		AtomLinks []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
+		Language    string    `xml:"language"`
+		Generator   string    `xml:"generator"`
+		TTL         string    `xml:"ttl"` // minutes the channel may be cached
 		Item        []RSSItem `xml:"item"`
+
+		// Channel artwork; the iTunes image is declared first so it is not mistaken for <image>
+		ITunesImage struct {
+			Href string `xml:"href,attr"`
+		} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		Image struct {
+			URL string `xml:"url"`
+		} `xml:"image"`
+
+		// Syndication module hints: the feed updates UpdateFrequency times per UpdatePeriod
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+
+		// Hours (GMT) and days during which aggregators should not fetch the feed
+		SkipHours []string `xml:"skipHours>hour"`
+		SkipDays  []string `xml:"skipDays>day"`
 	} `xml:"channel"`
 }
 
+// ImageURL returns the channel's logo or artwork, preferring <image> over <itunes:image>
+func (f *RSSFeed) ImageURL() string {
+	if url := strings.TrimSpace(f.Channel.Image.URL); url != "" {
+		return url
+	}
+	return strings.TrimSpace(f.Channel.ITunesImage.Href)
+}
+
 // RSSItem represents an item in an RSS feed
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"` // full HTML body
+	PubDate     string         `xml:"pubDate"`
+	GUID        string         `xml:"guid"`
+	Authors     []string       `xml:"author"`
+	Categories  []string       `xml:"category"`
+	Enclosures  []RSSEnclosure `xml:"enclosure"`
+
+	// Dublin Core creators, which many feeds use instead of <author>
+	Creators []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+
+	// Media RSS files, either directly on the item or inside a <media:group>
+	MediaContents      []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroupContents []MediaContent `xml:"http://search.yahoo.com/mrss/ group>content"`
+
+	// iTunes podcast metadata
+	ITunesDuration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+}
+
+// RSSEnclosure represents a media file attached to an item
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// MediaContent represents a Media RSS <media:content> element
+type MediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"` // seconds
+}
+
+// FetchOptions holds per-request settings for Fetcher.Fetch
+type FetchOptions struct {
+	// ETag and LastModified are validators from a previous response,
+	// sent as If-None-Match and If-Modified-Since to make the request conditional
+	ETag         string
+	LastModified string
+
+	// Proxy overrides the fetcher's proxy for this feed (http://, https:// or socks5://)
+	Proxy string
+
+	// Headers are added to the request, e.g. Authorization for feeds behind a login
+	Headers http.Header
+}
+
+// FetchResult holds the outcome of a feed fetch
+type FetchResult struct {
+	Feed         *RSSFeed // nil when NotModified is true
+	StatusCode   int
+	NotModified  bool
+	ETag         string
+	LastModified string
+
+	// CacheLifetime is how long the response may be cached according to
+	// its Cache-Control and Expires headers, zero when it gives no hint
+	CacheLifetime time.Duration
+
+	// PermanentURL is where the feed has moved when the request was answered with a
+	// permanent redirect (301 or 308), empty otherwise. Temporary redirects (302, 303, 307)
+	// are followed but not reported, so the original URL keeps being used.
+	PermanentURL string
 }
 
-// FetchFeed retrieves and parses an RSS feed from the given URL
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+// Fetch retrieves and parses an RSS 2.0, RSS 1.0, Atom or JSON feed from the given URL.
+// When the options carry cache validators and the server answers 304 Not Modified,
+// the result has NotModified set and no feed.
+func (f *Fetcher) Fetch(ctx context.Context, feedURL string, opts FetchOptions) (*FetchResult, error) {
 	// Create a new HTTP request with context
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
 	if err != nil {
@@ -37,45 +138,173 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	}
 
 	// Set User-Agent header to identify our client
-	req.Header.Set("User-Agent", "gator") // Consider making User-Agent configurable
+	req.Header.Set("User-Agent", f.opts.UserAgent)
+	setHeaders(req, opts.Headers)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second, // Set a reasonable timeout to prevent hanging
+	// Make the request conditional when we have validators from a previous fetch
+	if opts.ETag != "" {
+		req.Header.Set("If-None-Match", opts.ETag)
+	}
+	if opts.LastModified != "" {
+		req.Header.Set("If-Modified-Since", opts.LastModified)
+	}
+
+	// Follow redirects but note permanent moves
+	redirects := redirectChain{headers: opts.Headers}
+	client, err := f.client(opts.Proxy, redirects.check)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching feed: %w", err)
+		return nil, &NetworkError{Err: err}
 	}
 	defer resp.Body.Close()
 
+	// Keep the new validators, falling back to the ones we sent if the server omits them
+	result := &FetchResult{
+		StatusCode:    resp.StatusCode,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		CacheLifetime: cacheLifetime(resp.Header, time.Now()),
+		PermanentURL:  redirects.permanentURL(),
+	}
+	if result.ETag == "" {
+		result.ETag = opts.ETag
+	}
+	if result.LastModified == "" {
+		result.LastModified = opts.LastModified
+	}
+
+	// Nothing changed since the last fetch
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		return result, nil
+	}
+
 	// Check response status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
+		if statusErr.Throttled() {
+			statusErr.RetryAfter = retryAfter(resp.Header, time.Now())
+		}
+		return nil, statusErr
 	}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+	// Refuse bodies announced as too large before reading any of them
+	if f.opts.MaxBodySize > 0 && resp.ContentLength > f.opts.MaxBodySize {
+		return nil, &SizeError{Limit: f.opts.MaxBodySize}
 	}
 
-	// Unmarshal XML into RSSFeed struct
-	var feed RSSFeed
-	if err := xml.Unmarshal(body, &feed); err != nil {
-		return nil, fmt.Errorf("error parsing RSS feed: %w", err)
+	// Decode the body as it arrives rather than reading it into a buffer first; the reader stops at the size limit
+	body := f.newBodyReader(resp.Body)
+	feed, err := parseFeed(body, resp.Header.Get("Content-Type"))
+	if failure := body.failure(); failure != nil {
+		return nil, failure
+	}
+	if err != nil {
+		return nil, &ParseError{Err: err}
 	}
 
-	// Decode HTML entities in channel fields
+	// Decode HTML entities in titles. Descriptions are HTML and are left for the renderer
+	// to decode, so escaped markup in them stays text.
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
-	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
-
-	// Decode HTML entities in item fields
 	for i := range feed.Channel.Item {
 		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
-		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
 	}
 
-	return &feed, nil
+	result.Feed = feed
+	return result, nil
+}
+
+// parseFeed detects the document format and decodes it into an RSSFeed as it is read, so the raw
+// body is never buffered whole. The decoded feed, with every item, is still built in memory before
+// it is returned; max_body_size is what bounds its size.
+func parseFeed(body io.Reader, contentType string) (*RSSFeed, error) {
+	br := bufio.NewReader(body)
+	prefix, err := firstByte(br)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing feed: %w", err)
+	}
+
+	if isJSONFeed(prefix, contentType) {
+		var jf jsonFeed
+		if err := json.NewDecoder(br).Decode(&jf); err != nil {
+			return nil, fmt.Errorf("error parsing JSON feed: %w", err)
+		}
+		return jf.toRSSFeed(), nil
+	}
+
+	// Everything else is XML, identified by its root element
+	decoder := xml.NewDecoder(br)
+	root, err := rootElement(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing feed: %w", err)
+	}
+
+	switch root.Name.Local {
+	case "feed":
+		// Atom 1.0 documents use <feed> with <entry> children
+		var atom atomFeed
+		if err := decoder.DecodeElement(&atom, &root); err != nil {
+			return nil, fmt.Errorf("error parsing Atom feed: %w", err)
+		}
+		return atom.toRSSFeed(), nil
+	case "rss":
+		var feed RSSFeed
+		if err := decoder.DecodeElement(&feed, &root); err != nil {
+			return nil, fmt.Errorf("error parsing RSS feed: %w", err)
+		}
+		return &feed, nil
+	case "RDF":
+		// RSS 1.0 documents wrap the channel and items in <rdf:RDF>
+		if root.Name.Space != rdfNamespace {
+			return nil, fmt.Errorf("unsupported feed format: <%s>", root.Name.Local)
+		}
+		var rdf rdfFeed
+		if err := decoder.DecodeElement(&rdf, &root); err != nil {
+			return nil, fmt.Errorf("error parsing RSS 1.0 feed: %w", err)
+		}
+		return rdf.toRSSFeed(), nil
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.Name.Local)
+	}
+}
+
+// firstByte skips a UTF-8 byte order mark and leading whitespace and returns the first byte
+// of the document without consuming it
+func firstByte(br *bufio.Reader) ([]byte, error) {
+	if bom, _ := br.Peek(3); string(bom) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+	for {
+		prefix, err := br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("empty document")
+			}
+			return nil, err
+		}
+		if !unicode.IsSpace(rune(prefix[0])) {
+			return prefix, nil
+		}
+		br.ReadByte()
+	}
+}
+
+// rootElement reads up to the first element of an XML document, leaving the decoder positioned after it
+func rootElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return xml.StartElement{}, errors.New("document has no root element")
+			}
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
 }
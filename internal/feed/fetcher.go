@@ -0,0 +1,245 @@
+package feed
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults used for FetcherOptions fields left at their zero value
+const (
+	DefaultTimeout   = 10 * time.Second
+	DefaultUserAgent = "gator"
+)
+
+// FetcherOptions configures a Fetcher
+type FetcherOptions struct {
+	Timeout     time.Duration // per request, including reading the body
+	UserAgent   string
+	MaxBodySize int64 // bytes, zero for no limit
+
+	// Proxy is the URL of the proxy used for every request (http://, https://, socks5://),
+	// empty to use the HTTP_PROXY/HTTPS_PROXY environment variables
+	Proxy string
+
+	// CABundle is the path of a PEM file with extra certificate authorities to trust
+	CABundle string
+
+	// Connection reuse
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+}
+
+// Fetcher downloads feeds and web pages over a transport that is shared by every request,
+// so connections are reused across feeds. It is safe for concurrent use.
+type Fetcher struct {
+	opts      FetcherOptions
+	transport *http.Transport
+
+	// Transports for feeds that set their own proxy, by proxy URL
+	mu             sync.Mutex
+	proxyTransport map[string]*http.Transport
+}
+
+// NewFetcher builds a Fetcher from the given options
+func NewFetcher(opts FetcherOptions) (*Fetcher, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+
+	if opts.Proxy != "" {
+		proxyURL, err := ParseProxy(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+		// Trust the bundle on top of the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &Fetcher{
+		opts:           opts,
+		transport:      transport,
+		proxyTransport: make(map[string]*http.Transport),
+	}, nil
+}
+
+// UserAgent returns the User-Agent header sent with every request
+func (f *Fetcher) UserAgent() string {
+	return f.opts.UserAgent
+}
+
+// Timeout returns the time limit of a single request
+func (f *Fetcher) Timeout() time.Duration {
+	return f.opts.Timeout
+}
+
+// TransferClient returns a client sharing the fetcher's transport but without an overall
+// timeout, for long downloads such as podcast episodes that are cancelled through their context
+func (f *Fetcher) TransferClient() *http.Client {
+	return &http.Client{Transport: f.transport}
+}
+
+// client returns an HTTP client for a single request, going through the given proxy when set.
+// Each request gets its own client so redirects can be tracked per request.
+func (f *Fetcher) client(proxy string, checkRedirect func(*http.Request, []*http.Request) error) (*http.Client, error) {
+	transport, err := f.transportFor(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport:     transport,
+		Timeout:       f.opts.Timeout,
+		CheckRedirect: checkRedirect,
+	}, nil
+}
+
+// transportFor returns the shared transport, or one derived from it for a per-feed proxy
+func (f *Fetcher) transportFor(proxy string) (*http.Transport, error) {
+	if proxy == "" {
+		return f.transport, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if transport, ok := f.proxyTransport[proxy]; ok {
+		return transport, nil
+	}
+
+	proxyURL, err := ParseProxy(proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := f.transport.Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	f.proxyTransport[proxy] = transport
+	return transport, nil
+}
+
+// setHeaders adds per-feed headers to a request, replacing any default with the same name.
+// redirectChain.check withholds them from redirects to another host.
+func setHeaders(req *http.Request, headers http.Header) {
+	for name, values := range headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+}
+
+// bodyReader wraps a response body, failing with a SizeError once more than limit bytes have been
+// read. It remembers why reading stopped, so a parser error caused by the size limit or a dropped
+// connection can be told apart from a malformed document.
+type bodyReader struct {
+	r     io.Reader
+	limit int64 // zero for no limit
+	read  int64
+	err   error // first error other than io.EOF
+}
+
+// newBodyReader limits a response body to the configured maximum size
+func (f *Fetcher) newBodyReader(body io.Reader) *bodyReader {
+	return &bodyReader{r: body, limit: f.opts.MaxBodySize}
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	// Read one byte past the limit to tell a body of exactly limit bytes from a larger one
+	if b.limit > 0 && int64(len(p)) > b.limit-b.read+1 {
+		p = p[:b.limit-b.read+1]
+	}
+
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.limit > 0 && b.read > b.limit {
+		b.err = &SizeError{Limit: b.limit}
+		return 0, b.err
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		b.err = err
+	}
+	return n, err
+}
+
+// failure returns the error that stopped reading, if any: a *SizeError or a *NetworkError
+func (b *bodyReader) failure() error {
+	var sizeErr *SizeError
+	switch {
+	case b.err == nil:
+		return nil
+	case errors.As(b.err, &sizeErr):
+		return sizeErr
+	default:
+		return &NetworkError{Err: fmt.Errorf("error reading response body: %w", b.err)}
+	}
+}
+
+// readBody reads a whole response body, failing once it grows past the configured maximum size
+func (f *Fetcher) readBody(body io.Reader) ([]byte, error) {
+	br := f.newBodyReader(body)
+	data, err := io.ReadAll(br)
+	if failure := br.failure(); failure != nil {
+		return nil, failure
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseProxy validates a proxy URL; net/http supports http, https and socks5 proxies
+func ParseProxy(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, errors.New("proxy URL must start with http://, https://, socks5:// or socks5h://")
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return proxyURL, nil
+}
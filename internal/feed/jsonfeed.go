@@ -0,0 +1,161 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// jsonFeed represents the structure of a JSON Feed (versions 1.0 and 1.1)
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Icon        string         `json:"icon"`
+	Favicon     string         `json:"favicon"`
+	Language    string         `json:"language"` // JSON Feed 1.1
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// jsonFeedItem represents an item in a JSON Feed
+type jsonFeedItem struct {
+	ID            jsonFeedID           `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Author        *jsonFeedAuthor      `json:"author"`  // JSON Feed 1.0
+	Authors       []jsonFeedAuthor     `json:"authors"` // JSON Feed 1.1
+	Tags          []string             `json:"tags"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+}
+
+// jsonFeedID is an item id. The spec makes it a string but asks readers to accept
+// numbers too, converting them to strings.
+type jsonFeedID string
+
+// UnmarshalJSON accepts both string and number ids
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = jsonFeedID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("item id must be a string or a number: %w", err)
+	}
+	*id = jsonFeedID(n.String())
+	return nil
+}
+
+// jsonFeedAuthor represents an author object in a JSON Feed
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// jsonFeedAttachment represents an attachment object in a JSON Feed
+type jsonFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+// isJSONFeed reports whether a response looks like a JSON Feed based on its content type or the start of its body
+func isJSONFeed(prefix []byte, contentType string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/feed+json", "application/json":
+			return true
+		}
+	}
+
+	// Some servers send JSON feeds as text/plain, so sniff the first character too
+	return bytes.HasPrefix(bytes.TrimSpace(prefix), []byte("{"))
+}
+
+// toRSSFeed maps a JSON Feed onto the RSSFeed model used by the aggregator
+func (j *jsonFeed) toRSSFeed() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = j.Title
+	feed.Channel.Link = j.HomePageURL
+	feed.Channel.Description = j.Description
+	feed.Channel.Language = j.Language
+
+	// Prefer the large icon over the favicon
+	feed.Channel.Image.URL = j.Icon
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = j.Favicon
+	}
+
+	for _, item := range j.Items {
+		// Prefer the summary, falling back to the full content
+		description := item.Summary
+		if description == "" {
+			description = item.ContentHTML
+		}
+		if description == "" {
+			description = item.ContentText
+		}
+
+		// Prefer the original publication date, falling back to the last modification
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+
+		// Prefer the HTML body, falling back to plain text
+		content := item.ContentHTML
+		if content == "" {
+			content = item.ContentText
+		}
+
+		rssItem := RSSItem{
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(item.URL),
+			Description: description,
+			Content:     content,
+			PubDate:     strings.TrimSpace(pubDate),
+			GUID:        strings.TrimSpace(string(item.ID)),
+			Categories:  item.Tags,
+		}
+
+		authors := item.Authors
+		if len(authors) == 0 && item.Author != nil {
+			authors = []jsonFeedAuthor{*item.Author}
+		}
+		for _, author := range authors {
+			if author.Name != "" {
+				rssItem.Authors = append(rssItem.Authors, author.Name)
+			}
+		}
+
+		// Attachments map onto Media RSS content, which also carries a duration
+		for _, attachment := range item.Attachments {
+			media := MediaContent{
+				URL:  attachment.URL,
+				Type: attachment.MimeType,
+			}
+			if attachment.SizeInBytes > 0 {
+				media.FileSize = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			if attachment.DurationInSeconds > 0 {
+				media.Duration = strconv.Itoa(int(attachment.DurationInSeconds))
+			}
+			rssItem.MediaContents = append(rssItem.MediaContents, media)
+		}
+
+		feed.Channel.Item = append(feed.Channel.Item, rssItem)
+	}
+
+	return &feed
+}
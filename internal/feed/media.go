@@ -0,0 +1,110 @@
+package feed
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attachment is a media file attached to an item, gathered from RSS enclosures,
+// Atom enclosure links, Media RSS and JSON Feed attachments
+type Attachment struct {
+	URL      string
+	Type     string
+	Length   int64         // bytes, zero when unknown
+	Duration time.Duration // zero when unknown
+}
+
+// Attachments returns the item's media files without duplicates. The iTunes duration,
+// which describes the episode, is applied to the first attachment lacking its own.
+func (item *RSSItem) Attachments() []Attachment {
+	var attachments []Attachment
+	seen := make(map[string]int)
+	add := func(attachment Attachment) {
+		attachment.URL = strings.TrimSpace(attachment.URL)
+		if attachment.URL == "" {
+			return
+		}
+		// The same file is often listed both as an enclosure and as Media RSS content
+		if i, ok := seen[attachment.URL]; ok {
+			existing := &attachments[i]
+			if existing.Type == "" {
+				existing.Type = attachment.Type
+			}
+			if existing.Length == 0 {
+				existing.Length = attachment.Length
+			}
+			if existing.Duration == 0 {
+				existing.Duration = attachment.Duration
+			}
+			return
+		}
+		seen[attachment.URL] = len(attachments)
+		attachments = append(attachments, attachment)
+	}
+
+	for _, enclosure := range item.Enclosures {
+		add(Attachment{
+			URL:    enclosure.URL,
+			Type:   strings.TrimSpace(enclosure.Type),
+			Length: parseLength(enclosure.Length),
+		})
+	}
+	for _, media := range slices.Concat(item.MediaContents, item.MediaGroupContents) {
+		add(Attachment{
+			URL:      media.URL,
+			Type:     strings.TrimSpace(media.Type),
+			Length:   parseLength(media.FileSize),
+			Duration: parseDuration(media.Duration),
+		})
+	}
+
+	if duration := parseDuration(item.ITunesDuration); duration > 0 {
+		for i := range attachments {
+			if attachments[i].Duration == 0 {
+				attachments[i].Duration = duration
+				break
+			}
+		}
+	}
+
+	return attachments
+}
+
+// Episode returns the iTunes episode number of the item, or zero when it has none
+func (item *RSSItem) Episode() int {
+	episode, err := strconv.Atoi(strings.TrimSpace(item.ITunesEpisode))
+	if err != nil || episode < 0 {
+		return 0
+	}
+	return episode
+}
+
+// parseLength parses a byte count, returning zero for missing or invalid values
+func parseLength(value string) int64 {
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	return length
+}
+
+// parseDuration parses a duration given in seconds or as [[HH:]MM:]SS,
+// returning zero for missing or invalid values
+func parseDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
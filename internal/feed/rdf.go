@@ -0,0 +1,69 @@
+package feed
+
+import "strings"
+
+// rdfNamespace is the namespace of the <rdf:RDF> root element of RSS 1.0 documents
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// rdfFeed represents the structure of an RSS 1.0 (RDF) feed, where items are siblings of the channel
+type rdfFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		Language    string `xml:"http://purl.org/dc/elements/1.1/ language"`
+
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Image struct {
+		URL string `xml:"url"`
+	} `xml:"image"`
+	Items []rdfItem `xml:"item"`
+}
+
+// rdfItem represents an item in an RSS 1.0 feed
+type rdfItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creators    []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+// toRSSFeed maps an RSS 1.0 feed onto the RSSFeed model used by the aggregator
+func (r *rdfFeed) toRSSFeed() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = strings.TrimSpace(r.Channel.Title)
+	feed.Channel.Link = strings.TrimSpace(r.Channel.Link)
+	feed.Channel.Description = strings.TrimSpace(r.Channel.Description)
+	feed.Channel.Language = strings.TrimSpace(r.Channel.Language)
+	feed.Channel.Image.URL = strings.TrimSpace(r.Image.URL)
+	feed.Channel.UpdatePeriod = strings.TrimSpace(r.Channel.UpdatePeriod)
+	feed.Channel.UpdateFrequency = strings.TrimSpace(r.Channel.UpdateFrequency)
+
+	for _, item := range r.Items {
+		rssItem := RSSItem{
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(item.Link),
+			Description: item.Description,
+			Content:     item.Content,
+			PubDate:     strings.TrimSpace(item.Date),
+			GUID:        strings.TrimSpace(item.About),
+			Categories:  item.Subjects,
+		}
+
+		for _, creator := range item.Creators {
+			if creator = strings.TrimSpace(creator); creator != "" {
+				rssItem.Authors = append(rssItem.Authors, creator)
+			}
+		}
+
+		feed.Channel.Item = append(feed.Channel.Item, rssItem)
+	}
+
+	return &feed
+}
@@ -0,0 +1,58 @@
+package feed
+
+import (
+	"errors"
+	"net/http"
+)
+
+// maxRedirects matches the limit of the default http.Client
+const maxRedirects = 10
+
+// redirectChain records the redirects followed while fetching a feed
+type redirectChain struct {
+	hops []redirectHop
+
+	// headers are the per-feed headers of the request, withheld from other hosts
+	headers http.Header
+}
+
+// redirectHop is a single redirect: the status that caused it and where it led
+type redirectHop struct {
+	statusCode int
+	location   string
+}
+
+// check is used as http.Client.CheckRedirect to record each hop before following it
+func (c *redirectChain) check(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("stopped after 10 redirects")
+	}
+	hop := redirectHop{location: req.URL.String()}
+	if req.Response != nil {
+		hop.statusCode = req.Response.StatusCode
+	}
+	c.hops = append(c.hops, hop)
+
+	// net/http only strips Authorization and Cookie when a redirect leaves the original host,
+	// so drop every per-feed header, such as an API key, before following it elsewhere
+	if req.URL.Host != via[0].URL.Host {
+		for name := range c.headers {
+			req.Header.Del(name)
+		}
+	}
+	return nil
+}
+
+// permanentURL returns where the leading permanent redirects of the chain point, empty when
+// the first redirect was temporary. Temporary hops after that are not followed up, since
+// only the permanently moved location should replace the stored URL.
+func (c *redirectChain) permanentURL() string {
+	location := ""
+	for _, hop := range c.hops {
+		if hop.statusCode != http.StatusMovedPermanently && hop.statusCode != http.StatusPermanentRedirect {
+			break
+		}
+		location = hop.location
+	}
+	return location
+}
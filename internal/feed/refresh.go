@@ -0,0 +1,110 @@
+package feed
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheLifetime returns how long a response may be cached according to its
+// Cache-Control and Expires headers, or zero when the headers give no hint
+func cacheLifetime(header http.Header, now time.Time) time.Duration {
+	// Cache-Control takes precedence over Expires
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age", "s-maxage":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+
+		// Measure against the server clock when it tells us the time
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			now = date
+		}
+		if lifetime := expiresAt.Sub(now); lifetime > 0 {
+			return lifetime
+		}
+	}
+
+	return 0
+}
+
+// UpdateInterval returns the publisher's declared minimum time between updates, taken
+// from the channel's <ttl> and syndication module elements, or zero when it declares none
+func (f *RSSFeed) UpdateInterval() time.Duration {
+	var interval time.Duration
+
+	// <ttl> is the number of minutes the channel may be cached
+	if minutes, err := strconv.Atoi(strings.TrimSpace(f.Channel.TTL)); err == nil && minutes > 0 {
+		interval = time.Duration(minutes) * time.Minute
+	}
+
+	// <sy:updatePeriod> and <sy:updateFrequency> say the feed updates frequency times per period
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(f.Channel.UpdatePeriod)) {
+	case "hourly":
+		period = time.Hour
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	case "monthly":
+		period = 30 * 24 * time.Hour
+	case "yearly":
+		period = 365 * 24 * time.Hour
+	}
+	if period > 0 {
+		frequency, err := strconv.Atoi(strings.TrimSpace(f.Channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			frequency = 1 // the module's default
+		}
+		interval = max(interval, period/time.Duration(frequency))
+	}
+
+	return interval
+}
+
+// SkipSchedule returns the valid hours (0-23, GMT) and day names from the channel's
+// <skipHours> and <skipDays> elements, dropping duplicates and unknown values.
+// The slices are never nil, so they can be stored directly in NOT NULL array columns.
+func (f *RSSFeed) SkipSchedule() (hours []int32, days []string) {
+	hours, days = []int32{}, []string{}
+
+	seenHours := make(map[int]bool)
+	for _, value := range f.Channel.SkipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || hour < 0 || hour > 24 {
+			continue
+		}
+		// Some publishers number hours 1-24, where 24 means midnight
+		hour %= 24
+		if !seenHours[hour] {
+			seenHours[hour] = true
+			hours = append(hours, int32(hour))
+		}
+	}
+
+	seenDays := make(map[string]bool)
+	for _, value := range f.Channel.SkipDays {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(strings.TrimSpace(value), day.String()) && !seenDays[day.String()] {
+				seenDays[day.String()] = true
+				days = append(days, day.String())
+			}
+		}
+	}
+
+	return hours, days
+}
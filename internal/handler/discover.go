@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/feed"
+)
+
+// discoverFeed resolves a URL given on the command line, which may be a feed or a web page,
+// to a single feed. When a page links to several feeds the user is asked to pick one.
+func discoverFeed(ctx context.Context, s *app.State, pageURL string, opts feed.FetchOptions) (feed.DiscoveredFeed, error) {
+	feeds, err := s.Fetcher.Discover(ctx, pageURL, opts)
+	if err != nil {
+		return feed.DiscoveredFeed{}, fmt.Errorf("failed to discover feeds at %s: %w", pageURL, err)
+	}
+	if len(feeds) == 0 {
+		return feed.DiscoveredFeed{}, fmt.Errorf("no feeds found at %s", pageURL)
+	}
+	return chooseFeed(feeds)
+}
+
+// chooseFeed returns the only feed in the list, or asks the user to pick one on stdin
+func chooseFeed(feeds []feed.DiscoveredFeed) (feed.DiscoveredFeed, error) {
+	if len(feeds) == 1 {
+		return feeds[0], nil
+	}
+
+	fmt.Println("Found several feeds:")
+	for i, f := range feeds {
+		if f.Title != "" {
+			fmt.Printf("%d. %s (%s)\n", i+1, f.Title, f.URL)
+		} else {
+			fmt.Printf("%d. %s\n", i+1, f.URL)
+		}
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Choose a feed [1-%d]: ", len(feeds))
+		line, err := reader.ReadString('\n')
+		if choice, convErr := strconv.Atoi(strings.TrimSpace(line)); convErr == nil && choice >= 1 && choice <= len(feeds) {
+			return feeds[choice-1], nil
+		}
+		if err != nil {
+			// Input ended without a valid choice
+			return feed.DiscoveredFeed{}, errors.New("no feed chosen")
+		}
+		fmt.Println("Invalid choice.")
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/database"
+)
+
+// headerList collects repeated -header "Name: value" flags
+type headerList []string
+
+func (h *headerList) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerList) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// authFlags are the request header options shared by addfeed and editfeed
+type authFlags struct {
+	basicAuth string
+	bearer    string
+	headers   headerList
+}
+
+// addAuthFlags registers the header options on a command's flag set
+func addAuthFlags(fs *flag.FlagSet) *authFlags {
+	a := &authFlags{}
+	fs.StringVar(&a.basicAuth, "basic-auth", "", "HTTP Basic credentials as user:password")
+	fs.StringVar(&a.bearer, "bearer", "", "bearer token sent in the Authorization header")
+	fs.Var(&a.headers, "header", "extra request header as \"Name: value\", may be repeated; an empty value removes the header")
+	return a
+}
+
+// apply adds the headers given on the command line to h, replacing headers with the same name
+func (a *authFlags) apply(h http.Header) error {
+	if a.basicAuth != "" && a.bearer != "" {
+		return errors.New("use either -basic-auth or -bearer, not both")
+	}
+
+	if a.basicAuth != "" {
+		if !strings.Contains(a.basicAuth, ":") {
+			return errors.New("-basic-auth must be given as user:password")
+		}
+		h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(a.basicAuth)))
+	}
+	if a.bearer != "" {
+		h.Set("Authorization", "Bearer "+a.bearer)
+	}
+
+	for _, header := range a.headers {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("invalid header %q, expected \"Name: value\"", header)
+		}
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if name == "User-Agent" {
+			return errors.New("the User-Agent is set with user_agent in the config")
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			h.Del(name)
+		} else {
+			h.Set(name, value)
+		}
+	}
+	return nil
+}
+
+// encryptHeaders serializes and encrypts a feed's request headers for storage, or returns nil when there are none
+func encryptHeaders(s *app.State, h http.Header) ([]byte, error) {
+	if len(h) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request headers: %w", err)
+	}
+	encrypted, err := s.SecretKey.Encrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt request headers: %w", err)
+	}
+	return encrypted, nil
+}
+
+// feedHeaders decrypts the request headers stored for a feed
+func feedHeaders(s *app.State, feedItem database.Feed) (http.Header, error) {
+	h := make(http.Header)
+	if len(feedItem.RequestHeaders) == 0 {
+		return h, nil
+	}
+
+	data, err := s.SecretKey.Decrypt(feedItem.RequestHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt request headers of feed %s: %w", feedItem.Name, err)
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("failed to decode request headers of feed %s: %w", feedItem.Name, err)
+	}
+	return h, nil
+}
+
+// headerNames returns the sorted names of the headers, so they can be shown without their secret values
+func headerNames(h http.Header) []string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/feed"
+)
+
+// getFeedByURL looks up a feed by its URL as typed by the user, so that variants differing
+// only in scheme, host case, port, trailing slash or tracking parameters find the same feed
+func getFeedByURL(ctx context.Context, s *app.State, rawURL string) (database.Feed, error) {
+	return s.Db.GetFeedByURL(ctx, database.GetFeedByURLParams{
+		CanonicalUrl: feed.CanonicalURL(rawURL),
+		Url:          rawURL,
+	})
+}
+
+// backfillCanonicalURLs fills in the canonical URL of feeds added before URLs were canonicalized,
+// so that getFeedByURL finds them by any spelling. Feeds that duplicate another are left for mergefeeds.
+func backfillCanonicalURLs(ctx context.Context, s *app.State) error {
+	feeds, err := s.Db.GetFeedURLs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %w", err)
+	}
+
+	duplicates := 0
+	for _, f := range feeds {
+		if f.CanonicalUrl.Valid {
+			continue
+		}
+		n, err := s.Db.SetFeedCanonicalURL(ctx, database.SetFeedCanonicalURLParams{
+			ID:           f.ID,
+			CanonicalUrl: feed.CanonicalURL(f.Url),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set canonical URL of feed %s: %w", f.Name, err)
+		}
+		if n == 0 {
+			duplicates++
+		}
+	}
+
+	if duplicates > 0 {
+		fmt.Printf("Found %d feed(s) duplicating another; run mergefeeds to merge them\n", duplicates)
+	}
+	return nil
+}
+
+// canonicalURL returns the duplicate-detection key stored alongside a feed URL
+func canonicalURL(feedURL string) sql.NullString {
+	return sql.NullString{String: feed.CanonicalURL(feedURL), Valid: true}
+}
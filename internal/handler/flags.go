@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"flag"
+	"io"
+)
+
+// parseFlags parses command flags that may appear before, between or after positional arguments
+// and returns the positional arguments in order
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		// The flag package stops at the first positional argument, so keep it and carry on
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -2,48 +2,136 @@ package handler
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Skufu/RSS/internal/app"
 	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/feed"
 	"github.com/google/uuid"
 )
 
-// HandlerAddFeed handles the addfeed command which adds a feed for the current user
+// HandlerAddFeed handles the addfeed command which adds a feed for the current user.
+// The url may also be a web page, whose feeds are discovered from its HTML.
+// With -auto-name only the url is given and the feed is named after its channel title.
+// With -proxy the feed is always fetched through the given proxy instead of the configured one,
+// and -basic-auth, -bearer and -header add credentials or other headers to its requests.
 func HandlerAddFeed(s *app.State, cmd app.Command, user database.User) error {
+	fs := flag.NewFlagSet("addfeed", flag.ContinueOnError)
+	autoName := fs.Bool("auto-name", false, "name the feed after its channel title")
+	proxy := fs.String("proxy", "", "fetch this feed through an http://, https:// or socks5:// proxy")
+	auth := addAuthFlags(fs)
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("invalid addfeed flags: %w", err)
+	}
+
 	// Check if we have the right number of arguments
-	if len(cmd.Args) < 2 {
+	var name, url string
+	switch {
+	case *autoName && len(args) >= 1:
+		url = args[0]
+	case !*autoName && len(args) >= 2:
+		name, url = args[0], args[1]
+	case *autoName:
+		return errors.New("addfeed -auto-name requires a url argument")
+	default:
 		return errors.New("addfeed command requires name and url arguments")
 	}
 
-	name := cmd.Args[0]
-	url := cmd.Args[1]
+	if *proxy != "" {
+		if _, err := feed.ParseProxy(*proxy); err != nil {
+			return err
+		}
+	}
+
+	// Credentials are stored encrypted, so fail before any request if they cannot be
+	headers := make(http.Header)
+	if err := auth.apply(headers); err != nil {
+		return err
+	}
+	requestHeaders, err := encryptHeaders(s, headers)
+	if err != nil {
+		return err
+	}
+	fetchOpts := feed.FetchOptions{Proxy: *proxy, Headers: headers}
 
-	// Create the feed
 	ctx := context.Background()
+
+	// The url may be a web page that links to its feeds
+	discovered, err := discoverFeed(ctx, s, url, fetchOpts)
+	if err != nil {
+		return err
+	}
+	if discovered.URL != url {
+		fmt.Printf("Using feed %s\n", discovered.URL)
+	}
+	url = feed.NormalizeURL(discovered.URL)
+
+	// Refuse another spelling of a feed that is already stored
+	existing, err := getFeedByURL(ctx, s, url)
+	if err == nil {
+		return fmt.Errorf("feed already exists as %q (%s)", existing.Name, existing.Url)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check for an existing feed: %w", err)
+	}
+
+	// Discovery has usually fetched the feed already; a feed only linked from a page
+	// is fetched here to read its channel title
+	feedData := discovered.Feed
+	if *autoName {
+		if feedData == nil {
+			result, err := s.Fetcher.Fetch(ctx, url, fetchOpts)
+			if err != nil {
+				return fmt.Errorf("failed to fetch feed: %w", err)
+			}
+			feedData = result.Feed
+		}
+		name = strings.TrimSpace(feedData.Channel.Title)
+		if name == "" {
+			return errors.New("feed has no title, add it with an explicit name instead")
+		}
+	}
+
+	// Create the feed
 	now := time.Now()
 	feedParams := database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: now,
-		UpdatedAt: now,
-		Name:      name,
-		Url:       url,
-		UserID:    user.ID,
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Name:           name,
+		Url:            url,
+		UserID:         user.ID,
+		CanonicalUrl:   canonicalURL(url),
+		ProxyUrl:       sql.NullString{String: *proxy, Valid: *proxy != ""},
+		RequestHeaders: requestHeaders,
 	}
 
-	feed, err := s.Db.CreateFeed(ctx, feedParams)
+	feedItem, err := s.Db.CreateFeed(ctx, feedParams)
 	if err != nil {
 		return fmt.Errorf("failed to create feed: %w", err)
 	}
 
+	// Store the channel details we already have rather than waiting for the aggregator
+	if feedData != nil {
+		if err := s.Db.UpdateFeedMetadata(ctx, feedMetadata(feedItem.ID, feedItem.Url, feedData)); err != nil {
+			return fmt.Errorf("failed to save feed metadata: %w", err)
+		}
+	}
+
 	// Print success message and feed data
 	fmt.Printf("Feed created successfully!\n")
-	fmt.Printf("ID: %s\n", feed.ID)
-	fmt.Printf("Created: %s\n", feed.CreatedAt.Format(time.RFC3339))
-	fmt.Printf("Name: %s\n", feed.Name)
-	fmt.Printf("URL: %s\n", feed.Url)
+	fmt.Printf("ID: %s\n", feedItem.ID)
+	fmt.Printf("Created: %s\n", feedItem.CreatedAt.Format(time.RFC3339))
+	fmt.Printf("Name: %s\n", feedItem.Name)
+	fmt.Printf("URL: %s\n", feedItem.Url)
 
 	// Create a feed follow record for the user who added the feed
 	feedFollowParams := database.CreateFeedFollowParams{
@@ -51,7 +139,7 @@ func HandlerAddFeed(s *app.State, cmd app.Command, user database.User) error {
 		CreatedAt: now,
 		UpdatedAt: now,
 		UserID:    user.ID,
-		FeedID:    feed.ID,
+		FeedID:    feedItem.ID,
 	}
 
 	feedFollow, err := s.Db.CreateFeedFollow(ctx, feedFollowParams)
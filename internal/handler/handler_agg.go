@@ -2,12 +2,17 @@ package handler
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
+	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -31,6 +36,7 @@ func parsePublishedAt(dateStr string) (time.Time, error) {
 		"Mon, 2 Jan 2006 15:04:05 -0700",
 		"02 Jan 2006 15:04:05 -0700",
 		"2006-01-02T15:04:05-07:00",
+		"2006-01-02T15:04Z07:00", // W3C-DTF without seconds, used by dc:date
 		"2006-01-02 15:04:05",
 		"2006-01-02",
 	}
@@ -44,125 +50,694 @@ func parsePublishedAt(dateStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
 }
 
-// scrapeFeeds is a helper function that fetches a single feed
-func scrapeFeeds(s *app.State) error {
-	ctx := context.Background()
+// postGUID returns the identity of an item within its feed: the GUID the feed provides,
+// or a hash of its link and title for feeds that have none
+func postGUID(item feed.RSSItem) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(item.Link) + "\n" + strings.TrimSpace(item.Title)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// contentHash fingerprints the parts of a post that publishers edit, to detect changed posts.
+// It matches the hash computed for existing posts in the 014_post_content migration.
+func contentHash(title, description, content string) string {
+	sum := sha256.Sum256([]byte(title + "\n" + description + "\n" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// postOutcome describes what savePost did with an item
+type postOutcome int
+
+const (
+	postUnchanged postOutcome = iota
+	postCreated
+	postUpdated
+)
+
+// savePost stores a feed item as a post along with its attachments,
+// updating the stored post in place if the publisher edited it.
+// resave is set on the first fetch of a feed with posts stored by earlier versions, see 025_feed_resave_posts;
+// unchanged posts then get the attachments, authors and categories those versions did not store.
+func savePost(ctx context.Context, s *app.State, feedItem database.Feed, item feed.RSSItem, resave bool) (postOutcome, error) {
+	// Try to parse the published date
+	var publishedAt sql.NullTime
+	if item.PubDate != "" {
+		if parsedTime, err := parsePublishedAt(item.PubDate); err == nil {
+			publishedAt = sql.NullTime{
+				Time:  parsedTime,
+				Valid: true,
+			}
+		} else {
+			// If parsing fails, just log and continue with a null time
+			fmt.Printf("Warning: Could not parse date '%s' for post '%s': %v\n",
+				item.PubDate, item.Title, err)
+		}
+	}
+
+	// Prepare post parameters
+	now := time.Now()
+	postParams := database.CreatePostParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Title:       strings.TrimSpace(item.Title),
+		Url:         strings.TrimSpace(item.Link),
+		Description: sql.NullString{String: strings.TrimSpace(item.Description), Valid: item.Description != ""},
+		Content:     sql.NullString{String: strings.TrimSpace(item.Content), Valid: item.Content != ""},
+		PublishedAt: publishedAt,
+		FeedID:      feedItem.ID,
+		Guid:        postGUID(item),
+		Episode:     sql.NullInt32{Int32: int32(item.Episode()), Valid: item.Episode() > 0},
+	}
+	postParams.ContentHash = contentHash(postParams.Title, postParams.Description.String, postParams.Content.String)
+
+	// Posts stored before guids were tracked only know their link, so claim the matching one
+	if resave && strings.TrimSpace(item.GUID) != "" {
+		err := s.Db.AdoptMigratedPost(ctx, database.AdoptMigratedPostParams{
+			Guid:   postParams.Guid,
+			FeedID: feedItem.ID,
+			Url:    postParams.Url,
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to match earlier post: %w", err)
+		}
+	}
+
+	// Create the post; an unchanged post that is already stored returns no row
+	post, err := s.Db.CreatePost(ctx, postParams)
+	outcome := postCreated
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if !resave {
+			return postUnchanged, nil
+		}
+		// Fill in what earlier versions did not store for the post
+		post.ID, err = s.Db.GetPostIDByGUID(ctx, database.GetPostIDByGUIDParams{
+			FeedID: feedItem.ID,
+			Guid:   postParams.Guid,
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to find stored post: %w", err)
+		}
+		outcome = postUnchanged
+	case err != nil:
+		return postUnchanged, err
+	case post.ID != postParams.ID:
+		// A new post keeps the ID we generated, so this is a stored post that was edited
+		outcome = postUpdated
+	}
+
+	// Save the media files attached to the post, dropping the ones the publisher removed
+	attachments := item.Attachments()
+	urls := make([]string, len(attachments))
+	for i, attachment := range attachments {
+		urls[i] = attachment.URL
+	}
+	if outcome != postCreated {
+		err := s.Db.DeleteRemovedEnclosures(ctx, database.DeleteRemovedEnclosuresParams{
+			PostID: post.ID,
+			Urls:   urls,
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to remove old attachments: %w", err)
+		}
+	}
+	for _, attachment := range attachments {
+		err := s.Db.CreateEnclosure(ctx, database.CreateEnclosureParams{
+			ID:              uuid.New(),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			PostID:          post.ID,
+			Url:             attachment.URL,
+			MimeType:        sql.NullString{String: attachment.Type, Valid: attachment.Type != ""},
+			Length:          sql.NullInt64{Int64: attachment.Length, Valid: attachment.Length > 0},
+			DurationSeconds: sql.NullInt32{Int32: int32(attachment.Duration.Seconds()), Valid: attachment.Duration > 0},
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to save attachment %s: %w", attachment.URL, err)
+		}
+	}
+
+	// Replace the post's authors and categories with the ones from this version
+	if err := s.Db.DeletePostAuthors(ctx, post.ID); err != nil {
+		return postUnchanged, fmt.Errorf("failed to clear authors: %w", err)
+	}
+	for i, name := range item.AuthorNames() {
+		authorID, err := s.Db.CreateAuthor(ctx, database.CreateAuthorParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			Name:      name,
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to save author %s: %w", name, err)
+		}
+		err = s.Db.AddPostAuthor(ctx, database.AddPostAuthorParams{
+			PostID:   post.ID,
+			AuthorID: authorID,
+			Position: int32(i),
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to link author %s: %w", name, err)
+		}
+	}
+
+	if err := s.Db.DeletePostCategories(ctx, post.ID); err != nil {
+		return postUnchanged, fmt.Errorf("failed to clear categories: %w", err)
+	}
+	for i, name := range item.CategoryNames() {
+		categoryID, err := s.Db.CreateCategory(ctx, database.CreateCategoryParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			Name:      name,
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to save category %s: %w", name, err)
+		}
+		err = s.Db.AddPostCategory(ctx, database.AddPostCategoryParams{
+			PostID:     post.ID,
+			CategoryID: categoryID,
+			Position:   int32(i),
+		})
+		if err != nil {
+			return postUnchanged, fmt.Errorf("failed to link category %s: %w", name, err)
+		}
+	}
+
+	return outcome, nil
+}
 
-	// Get the next feed to fetch
-	feedItem, err := s.Db.GetNextFeedToFetch(ctx)
+// feedMetadata collects the channel details of a fetched feed, resolving relative links against the feed URL
+func feedMetadata(feedID uuid.UUID, feedURL string, feedData *feed.RSSFeed) database.UpdateFeedMetadataParams {
+	text := func(value string) sql.NullString {
+		value = strings.TrimSpace(value)
+		return sql.NullString{String: value, Valid: value != ""}
+	}
+	link := func(value string) sql.NullString {
+		value = strings.TrimSpace(value)
+		if base, err := url.Parse(feedURL); err == nil && value != "" {
+			if ref, err := url.Parse(value); err == nil {
+				value = base.ResolveReference(ref).String()
+			}
+		}
+		return text(value)
+	}
+
+	return database.UpdateFeedMetadataParams{
+		ID:          feedID,
+		Title:       text(feedData.Channel.Title),
+		SiteUrl:     link(feedData.Channel.Link),
+		Description: text(feedData.Channel.Description),
+		Language:    text(feedData.Channel.Language),
+		ImageUrl:    link(feedData.ImageURL()),
+		Generator:   text(feedData.Channel.Generator),
+	}
+}
+
+// moveFeed points a feed at the URL it was permanently redirected to, keeping the old URL
+// in the feed's history so it can still be followed and unfollowed by it
+func moveFeed(ctx context.Context, s *app.State, feedItem *database.Feed, location string) error {
+	newURL := feed.NormalizeURL(location)
+	if newURL == feedItem.Url {
+		return nil
+	}
+
+	// Stored credentials were given for the original host; following the feed elsewhere
+	// would send them to whoever the publisher, or an attacker, redirects to
+	if len(feedItem.RequestHeaders) > 0 && urlHost(newURL) != urlHost(feedItem.Url) {
+		return fmt.Errorf("feed %s moved to %s on another host; keeping the old URL because the feed has stored request headers, add the new URL with addfeed if you trust it", feedItem.Name, newURL)
+	}
+
+	// A different spelling of the same URL, such as an upgrade to https, needs no history
+	if feed.CanonicalURL(newURL) != feed.CanonicalURL(feedItem.Url) {
+		other, err := getFeedByURL(ctx, s, newURL)
+		if err == nil && other.ID != feedItem.ID {
+			return fmt.Errorf("feed %s moved to %s, which is already stored as %q; keeping the old URL", feedItem.Name, newURL, other.Name)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to check new URL of feed %s: %w", feedItem.Name, err)
+		}
+
+		err = s.Db.AddFeedURLHistory(ctx, database.AddFeedURLHistoryParams{
+			ID:           uuid.New(),
+			CreatedAt:    time.Now(),
+			FeedID:       feedItem.ID,
+			Url:          feedItem.Url,
+			CanonicalUrl: feed.CanonicalURL(feedItem.Url),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record previous URL of feed %s: %w", feedItem.Name, err)
+		}
+	}
+
+	err := s.Db.UpdateFeedURL(ctx, database.UpdateFeedURLParams{
+		ID:           feedItem.ID,
+		Url:          newURL,
+		CanonicalUrl: canonicalURL(newURL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update URL of feed %s: %w", feedItem.Name, err)
+	}
+
+	fmt.Printf("Feed: %s moved permanently from %s to %s\n", feedItem.Name, feedItem.Url, newURL)
+	feedItem.Url = newURL
+	return nil
+}
+
+// urlHost returns the lowercase host name of a URL, empty when it cannot be parsed
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		return fmt.Errorf("failed to get next feed: %w", err)
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// scrapeResult summarizes the outcome of fetching a single feed
+type scrapeResult struct {
+	notModified bool
+	disabled    bool
+	saved       int
+	updated     int
+	err         error
+}
+
+// statusCode returns the HTTP status carried by a fetch error, if the server responded at all
+func statusCode(err error) sql.NullInt32 {
+	var statusErr *feed.StatusError
+	if errors.As(err, &statusErr) {
+		return sql.NullInt32{Int32: int32(statusErr.StatusCode), Valid: true}
+	}
+	return sql.NullInt32{}
+}
+
+// Kinds of fetch failure recorded in feeds.last_error_kind
+const (
+	errorKindNetwork = "network" // the feed could not be reached, usually transient
+	errorKindHTTP    = "http"    // the publisher answered with an error status
+	errorKindParse   = "parse"   // the publisher answered with something that is not a readable feed
+	errorKindSize    = "size"    // the feed is larger than max_body_size
+)
+
+// fetchErrorKind classifies a fetch error for the feed health records
+func fetchErrorKind(err error) sql.NullString {
+	var (
+		networkErr *feed.NetworkError
+		statusErr  *feed.StatusError
+		parseErr   *feed.ParseError
+		sizeErr    *feed.SizeError
+	)
+	switch {
+	case errors.As(err, &networkErr):
+		return sql.NullString{String: errorKindNetwork, Valid: true}
+	case errors.As(err, &statusErr):
+		return sql.NullString{String: errorKindHTTP, Valid: true}
+	case errors.As(err, &parseErr):
+		return sql.NullString{String: errorKindParse, Valid: true}
+	case errors.As(err, &sizeErr):
+		return sql.NullString{String: errorKindSize, Valid: true}
+	}
+	return sql.NullString{}
+}
+
+// recordFailure stores a failed fetch on the feed, backing off its next fetch
+// and disabling it once it reaches the configured failure threshold.
+// A feed that is gone (410) is disabled at once. A server asking us to come back later
+// (429 or 503 with Retry-After) is waited for, up to the longest refresh interval, and the
+// answer does not count as a failure since the feed itself is fine.
+func recordFailure(ctx context.Context, s *app.State, feedItem database.Feed, fetchErr error, bounds refreshBounds) scrapeResult {
+	var statusErr *feed.StatusError
+	gone := errors.As(fetchErr, &statusErr) && statusErr.Gone()
+	throttled := statusErr != nil && statusErr.Throttled() && statusErr.RetryAfter > 0
+
+	failures := feedItem.ConsecutiveFailures + 1
+	disabled := int(failures) >= s.Cfg.MaxFeedFailures
+	retryIn := failureBackoff(failures)
+	if throttled {
+		failures = feedItem.ConsecutiveFailures
+		disabled = false
+		retryIn = min(statusErr.RetryAfter, bounds.max)
+	}
+
+	skip := newSkipSchedule(feedItem.SkipHours, feedItem.SkipDays)
+	now := time.Now()
+	params := database.MarkFeedFetchFailedParams{
+		ID:                  feedItem.ID,
+		ConsecutiveFailures: failures,
+		LastError:           sql.NullString{String: fetchErr.Error(), Valid: true},
+		LastErrorKind:       fetchErrorKind(fetchErr),
+		LastStatusCode:      statusCode(fetchErr),
+		NextFetchInSeconds:  secondsUntil(now, skip.adjust(now.Add(retryIn))),
+		Disabled:            disabled || gone,
+	}
+
+	if err := s.Db.MarkFeedFetchFailed(ctx, params); err != nil {
+		return scrapeResult{err: fmt.Errorf("failed to record fetch failure for %s: %w", feedItem.Name, err)}
+	}
+
+	if throttled {
+		return scrapeResult{err: fmt.Errorf("feed %s is rate limited, retrying in %s: %w", feedItem.Name, retryIn.Round(time.Second), fetchErr)}
 	}
 
-	fmt.Printf("Fetching feed: %s (%s)\n", feedItem.Name, feedItem.Url)
+	err := fmt.Errorf("failed to fetch feed %s (failure %d): %w", feedItem.Name, failures, fetchErr)
+	switch {
+	case gone:
+		err = fmt.Errorf("%w; the publisher removed the feed, disabled permanently", err)
+	case disabled:
+		err = fmt.Errorf("%w; feed disabled after %d consecutive failures", err, failures)
+	}
+	return scrapeResult{err: err, disabled: disabled || gone}
+}
 
+// scrapeFeed fetches a single feed and saves its new posts
+func scrapeFeed(ctx context.Context, s *app.State, feedItem database.Feed, bounds refreshBounds) scrapeResult {
 	// Mark feed as fetched first to avoid issues with failed fetches
-	err = s.Db.MarkFeedFetched(ctx, feedItem.ID)
+	err := s.Db.MarkFeedFetched(ctx, feedItem.ID)
 	if err != nil {
-		return fmt.Errorf("failed to mark feed as fetched: %w", err)
+		return scrapeResult{err: fmt.Errorf("failed to mark feed as fetched: %w", err)}
 	}
 
-	// Fetch the feed
-	feedData, err := feed.FetchFeed(ctx, feedItem.Url)
+	// A missing or rotated secret key fails like any fetch, so the feed backs off and shows in feedhealth
+	headers, err := feedHeaders(s, feedItem)
 	if err != nil {
-		// Just log the error and return nil so we continue with other feeds
-		fmt.Printf("Error: failed to fetch feed %s: %v\n", feedItem.Name, err)
-		return nil
+		return recordFailure(ctx, s, feedItem, err, bounds)
+	}
+
+	// Fetch the feed, sending the validators from the previous fetch
+	// unless every item has to be saved again
+	opts := feed.FetchOptions{
+		ETag:         feedItem.Etag.String,
+		LastModified: feedItem.LastModified.String,
+		Proxy:        feedItem.ProxyUrl.String,
+		Headers:      headers,
+	}
+	if feedItem.ResavePosts {
+		opts.ETag, opts.LastModified = "", ""
+	}
+	result, err := s.Fetcher.Fetch(ctx, feedItem.Url, opts)
+	if err != nil {
+		// An interrupted fetch says nothing about the health of the feed
+		if ctx.Err() != nil {
+			return scrapeResult{err: ctx.Err()}
+		}
+		return recordFailure(ctx, s, feedItem, err, bounds)
+	}
+
+	// Switch to the new location when the publisher moved the feed permanently
+	if result.PermanentURL != "" {
+		if err := moveFeed(ctx, s, &feedItem, result.PermanentURL); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	// Pick up the quiet windows the publisher declares, keeping the stored ones on a 304
+	skipHours, skipDays := feedItem.SkipHours, feedItem.SkipDays
+	if result.Feed != nil {
+		skipHours, skipDays = result.Feed.SkipSchedule()
+		err = s.Db.UpdateFeedSkipSchedule(ctx, database.UpdateFeedSkipScheduleParams{
+			ID:        feedItem.ID,
+			SkipHours: skipHours,
+			SkipDays:  skipDays,
+		})
+		if err != nil {
+			return scrapeResult{err: fmt.Errorf("failed to update skip schedule: %w", err)}
+		}
+	}
+
+	// Schedule the next fetch from how often the feed publishes, outside its quiet windows
+	previous := time.Duration(feedItem.FetchIntervalSeconds.Int32) * time.Second
+	interval := nextFetchInterval(result, previous, bounds)
+	now := time.Now()
+	nextFetchAt := newSkipSchedule(skipHours, skipDays).adjust(now.Add(interval))
+
+	// Any successful response, including 304, clears the failure state
+	err = s.Db.MarkFeedFetchSucceeded(ctx, database.MarkFeedFetchSucceededParams{
+		ID:                   feedItem.ID,
+		LastStatusCode:       sql.NullInt32{Int32: int32(result.StatusCode), Valid: true},
+		NextFetchInSeconds:   secondsUntil(now, nextFetchAt),
+		FetchIntervalSeconds: sql.NullInt32{Int32: int32(interval.Seconds()), Valid: true},
+	})
+	if err != nil {
+		return scrapeResult{err: fmt.Errorf("failed to record fetch success: %w", err)}
+	}
+
+	// A 304 response means there are no new items to save
+	if result.NotModified {
+		fmt.Printf("Feed: %s (not modified, next fetch %s)\n", feedItem.Name, formatLastTime(nextFetchAt, true))
+		return scrapeResult{notModified: true}
+	}
+
+	// Keep the channel details current
+	feedData := result.Feed
+	if err := s.Db.UpdateFeedMetadata(ctx, feedMetadata(feedItem.ID, feedItem.Url, feedData)); err != nil {
+		return scrapeResult{err: fmt.Errorf("failed to update feed metadata: %w", err)}
 	}
 
-	// Print the feed information
-	fmt.Printf("Feed: %s\n", feedItem.Name)
-	fmt.Printf("Items: %d\n", len(feedData.Channel.Item))
+	fmt.Printf("Feed: %s (%d items, next fetch %s)\n", feedItem.Name, len(feedData.Channel.Item), formatLastTime(nextFetchAt, true))
 
 	// Save each post to the database
+	saved, updated, failed := 0, 0, 0
 	for _, item := range feedData.Channel.Item {
-		// Try to parse the published date
-		var publishedAt sql.NullTime
-		if item.PubDate != "" {
-			if parsedTime, err := parsePublishedAt(item.PubDate); err == nil {
-				publishedAt = sql.NullTime{
-					Time:  parsedTime,
-					Valid: true,
-				}
-			} else {
-				// If parsing fails, just log and continue with a null time
-				fmt.Printf("Warning: Could not parse date '%s' for post '%s': %v\n",
-					item.PubDate, item.Title, err)
-			}
+		outcome, err := savePost(ctx, s, feedItem, item, feedItem.ResavePosts)
+		switch {
+		case err != nil:
+			// Log but continue processing
+			fmt.Printf("Error saving post '%s': %v\n", item.Title, err)
+			failed++
+		case outcome == postCreated:
+			fmt.Printf("  → Saved: %s\n", item.Title)
+			saved++
+		case outcome == postUpdated:
+			fmt.Printf("  → Updated: %s\n", item.Title)
+			updated++
 		}
+	}
 
-		// Prepare post parameters
-		now := time.Now()
-		postParams := database.CreatePostParams{
-			ID:          uuid.New(),
-			CreatedAt:   now,
-			UpdatedAt:   now,
-			Title:       strings.TrimSpace(item.Title),
-			Url:         strings.TrimSpace(item.Link),
-			Description: sql.NullString{String: strings.TrimSpace(item.Description), Valid: item.Description != ""},
-			PublishedAt: publishedAt,
-			FeedID:      feedItem.ID,
+	// Remember the validators for the next conditional fetch, but only once every item is stored;
+	// otherwise the next fetch would get a 304 and never retry the items that failed
+	if failed == 0 {
+		err = s.Db.UpdateFeedCacheHeaders(ctx, database.UpdateFeedCacheHeadersParams{
+			ID:           feedItem.ID,
+			Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
+			LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
+		})
+		if err != nil {
+			return scrapeResult{err: fmt.Errorf("failed to update cache headers: %w", err)}
 		}
+		if feedItem.ResavePosts {
+			if err := s.Db.ClearFeedResavePosts(ctx, feedItem.ID); err != nil {
+				return scrapeResult{err: fmt.Errorf("failed to clear resave flag: %w", err)}
+			}
+		}
+	}
 
-		// Create the post, ignoring duplicate URL errors
-		_, err := s.Db.CreatePost(ctx, postParams)
-		if err != nil {
-			// Check if it's a unique constraint violation on the URL
-			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") &&
-				strings.Contains(err.Error(), "posts_url_key") {
-				// Silently ignore duplicate URLs
-				continue
+	return scrapeResult{saved: saved, updated: updated}
+}
+
+// leaseTimeoutFactor is how many fetch timeouts a feed lease must last at least
+const leaseTimeoutFactor = 3
+
+// aggOptions holds the settings of a running aggregator
+type aggOptions struct {
+	batchSize   int
+	concurrency int
+	lease       time.Duration // how long a claimed feed stays reserved for this aggregator
+	owner       string        // identifies this aggregator process in feed leases
+	refresh     refreshBounds
+}
+
+// newLeaseOwner returns an identifier for this aggregator process that is unique across machines
+func newLeaseOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+}
+
+// scrapeFeeds claims the next batch of stale feeds and fetches them using a bounded pool of workers
+func scrapeFeeds(ctx context.Context, s *app.State, opts aggOptions) error {
+	start := time.Now()
+
+	// Claim the next batch of feeds, skipping feeds leased by other aggregators
+	feeds, err := s.Db.ClaimFeedsToFetch(ctx, database.ClaimFeedsToFetchParams{
+		LeaseOwner:   opts.owner,
+		LeaseSeconds: int32(opts.lease.Seconds()),
+		BatchSize:    int32(opts.batchSize),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to claim feeds: %w", err)
+	}
+	if len(feeds) == 0 {
+		fmt.Println("No feeds due for a fetch.")
+		return nil
+	}
+
+	// Hand the feeds out to the workers
+	jobs := make(chan database.Feed)
+	results := make(chan scrapeResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(opts.concurrency, len(feeds)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feedItem := range jobs {
+				// The feed may have waited behind others in the batch, so its lease could be running out
+				if !renewLease(ctx, s, feedItem, opts) {
+					continue
+				}
+				results <- scrapeFeed(ctx, s, feedItem, opts.refresh)
+				releaseLease(s, feedItem.ID, opts.owner)
 			}
+		}()
+	}
 
-			// For other errors, log but continue processing
-			fmt.Printf("Error saving post '%s': %v\n", item.Title, err)
-		} else {
-			fmt.Printf("  → Saved: %s\n", item.Title)
+	go func() {
+		defer close(jobs)
+		for _, feedItem := range feeds {
+			select {
+			case jobs <- feedItem:
+			case <-ctx.Done():
+				// Hand unstarted feeds back so other aggregators can pick them up
+				releaseLease(s, feedItem.ID, opts.owner)
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect the results for the tick report
+	fetched, notModified, failed, disabled, saved, updated := 0, 0, 0, 0, 0, 0
+	for result := range results {
+		switch {
+		case result.err != nil:
+			// Just log the error so we continue with other feeds
+			fmt.Printf("Error: %v\n", result.err)
+			failed++
+			if result.disabled {
+				disabled++
+			}
+		case result.notModified:
+			notModified++
+		default:
+			fetched++
+			saved += result.saved
+			updated += result.updated
 		}
 	}
 
-	fmt.Println()
+	fmt.Printf("Tick complete in %v: %d fetched, %d not modified, %d failed (%d disabled), %d new posts, %d updated\n\n",
+		time.Since(start).Round(time.Millisecond), fetched, notModified, failed, disabled, saved, updated)
 
 	return nil
 }
 
-// HandlerAgg handles the agg command which fetches and displays feeds in a loop
+// renewLease extends the lease on a claimed feed before it is fetched, reporting false
+// when the lease has been lost to another aggregator and the feed must be skipped
+func renewLease(ctx context.Context, s *app.State, feedItem database.Feed, opts aggOptions) bool {
+	renewed, err := s.Db.RenewFeedLease(ctx, database.RenewFeedLeaseParams{
+		ID:           feedItem.ID,
+		LeaseOwner:   opts.owner,
+		LeaseSeconds: int32(opts.lease.Seconds()),
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to renew lease on feed %s, skipping it: %v\n", feedItem.Name, err)
+		return false
+	}
+	if renewed == 0 {
+		fmt.Printf("Skipping feed %s: its lease expired and another aggregator took it\n", feedItem.Name)
+		return false
+	}
+	return true
+}
+
+// releaseLease gives a claimed feed back; if this fails the lease simply expires
+func releaseLease(s *app.State, feedID uuid.UUID, owner string) {
+	// Use a fresh context so leases are still released after Ctrl+C
+	err := s.Db.ReleaseFeedLease(context.Background(), database.ReleaseFeedLeaseParams{
+		ID:         feedID,
+		LeaseOwner: owner,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to release lease on feed %s: %v\n", feedID, err)
+	}
+}
+
+// HandlerAgg handles the agg command which fetches feeds in batches on an interval
 func HandlerAgg(s *app.State, cmd app.Command) error {
+	fs := flag.NewFlagSet("agg", flag.ContinueOnError)
+	concurrency := fs.Int("concurrency", 5, "number of feeds to fetch in parallel")
+	batchSize := fs.Int("batch", 10, "number of feeds to fetch per tick")
+	lease := fs.Duration("lease", 5*time.Minute, "how long a claimed feed is reserved before another aggregator may take it")
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("invalid agg flags: %w", err)
+	}
+
 	// Check for time_between_reqs argument
-	if len(cmd.Args) < 1 {
+	if len(args) < 1 {
 		return errors.New("agg command requires a time_between_reqs argument (e.g. 10s, 1m, 1h)")
 	}
+	if *concurrency < 1 || *batchSize < 1 {
+		return errors.New("concurrency and batch must be at least 1")
+	}
+	// A lease covers one fetch, which may take up to the fetch timeout plus the time to save its posts
+	if minLease := leaseTimeoutFactor * s.Fetcher.Timeout(); *lease < minLease {
+		return fmt.Errorf("lease must be at least %v, %d times the fetch timeout", minLease, leaseTimeoutFactor)
+	}
 
 	// Parse the duration
-	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
+	timeBetweenRequests, err := time.ParseDuration(args[0])
 	if err != nil {
 		return fmt.Errorf("invalid duration format: %w", err)
 	}
 
-	fmt.Printf("Starting feed aggregator. Collecting feeds every %v. Press Ctrl+C to stop.\n", timeBetweenRequests)
+	refresh, err := parseRefreshBounds(s.Cfg)
+	if err != nil {
+		return err
+	}
 
-	// Set up a channel to handle interrupts
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	opts := aggOptions{
+		batchSize:   *batchSize,
+		concurrency: *concurrency,
+		lease:       *lease,
+		owner:       newLeaseOwner(),
+		refresh:     refresh,
+	}
+
+	fmt.Printf("Starting feed aggregator %s. Collecting up to %d feeds every %v with %d workers. Press Ctrl+C to stop.\n",
+		opts.owner, opts.batchSize, timeBetweenRequests, opts.concurrency)
+
+	// Cancel in-flight fetches on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Feeds added before URLs were canonicalized only match their exact URL until this runs
+	if err := backfillCanonicalURLs(ctx, s); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
 
 	// Create a ticker for periodic feed scraping
 	ticker := time.NewTicker(timeBetweenRequests)
 	defer ticker.Stop()
 
-	// Start the scraping loop in a goroutine
-	go func() {
-		// Run immediately without waiting for first tick
-		for ; ; <-ticker.C {
-			err := scrapeFeeds(s)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
+	// Run immediately without waiting for first tick
+	for {
+		if err := scrapeFeeds(ctx, s, opts); err != nil && ctx.Err() == nil {
+			fmt.Printf("Error: %v\n", err)
 		}
-	}()
-
-	// Wait for interrupt signal
-	<-sigChan
-	fmt.Println("\nStopping feed aggregator.")
 
-	return nil
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopping feed aggregator.")
+			return nil
+		case <-ticker.C:
+		}
+	}
 }
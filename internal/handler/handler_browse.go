@@ -2,12 +2,17 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"flag"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Skufu/RSS/internal/app"
 	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/render"
+	"github.com/google/uuid"
 )
 
 // formatTime returns a human-readable string for a time
@@ -15,12 +20,56 @@ func formatTime(t time.Time) string {
 	return t.Format("Jan 02, 2006")
 }
 
+// formatEnclosure returns a one-line summary of an attachment: its URL followed by
+// whatever is known of its type, duration and size
+func formatEnclosure(enclosure database.Enclosure) string {
+	var details []string
+	if enclosure.MimeType.Valid {
+		details = append(details, enclosure.MimeType.String)
+	}
+	if enclosure.DurationSeconds.Valid {
+		details = append(details, (time.Duration(enclosure.DurationSeconds.Int32) * time.Second).String())
+	}
+	if enclosure.Length.Valid {
+		details = append(details, formatBytes(enclosure.Length.Int64))
+	}
+
+	if len(details) == 0 {
+		return enclosure.Url
+	}
+	return fmt.Sprintf("%s (%s)", enclosure.Url, strings.Join(details, ", "))
+}
+
+// formatBytes returns a human-readable file size
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 // HandlerBrowse handles the browse command which displays posts from feeds the user is following
 func HandlerBrowse(s *app.State, cmd app.Command, user database.User) error {
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	markChanged := fs.Bool("changed", false, "flag posts updated since you last saw them")
+	author := fs.String("author", "", "only show posts by this author")
+	category := fs.String("category", "", "only show posts in this category")
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("invalid browse flags: %w", err)
+	}
+
 	// Default limit to 20 if not provided
 	limit := int32(20)
-	if len(cmd.Args) > 0 {
-		parsedLimit, err := strconv.Atoi(cmd.Args[0])
+	if len(args) > 0 {
+		parsedLimit, err := strconv.Atoi(args[0])
 		if err != nil {
 			return fmt.Errorf("invalid limit parameter: %w", err)
 		}
@@ -30,8 +79,10 @@ func HandlerBrowse(s *app.State, cmd app.Command, user database.User) error {
 	// Get posts for the user
 	ctx := context.Background()
 	posts, err := s.Db.GetPostsForUser(ctx, database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  limit,
+		UserID:   user.ID,
+		Author:   sql.NullString{String: *author, Valid: *author != ""},
+		Category: sql.NullString{String: *category, Valid: *category != ""},
+		Limit:    limit,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to get posts: %w", err)
@@ -43,37 +94,103 @@ func HandlerBrowse(s *app.State, cmd app.Command, user database.User) error {
 		return nil
 	}
 
+	// Get the attachments of the posts, grouped by post
+	postIDs := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+	enclosures, err := s.Db.GetEnclosuresForPosts(ctx, postIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get attachments: %w", err)
+	}
+	attachments := make(map[uuid.UUID][]database.Enclosure)
+	for _, enclosure := range enclosures {
+		attachments[enclosure.PostID] = append(attachments[enclosure.PostID], enclosure)
+	}
+
+	// Get the authors and categories of the posts, grouped by post
+	authorRows, err := s.Db.GetAuthorsForPosts(ctx, postIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get authors: %w", err)
+	}
+	authors := make(map[uuid.UUID][]string)
+	for _, row := range authorRows {
+		authors[row.PostID] = append(authors[row.PostID], row.Name)
+	}
+	categoryRows, err := s.Db.GetCategoriesForPosts(ctx, postIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	categories := make(map[uuid.UUID][]string)
+	for _, row := range categoryRows {
+		categories[row.PostID] = append(categories[row.PostID], row.Name)
+	}
+
 	// Print header
 	fmt.Printf("Latest %d posts from your feeds:\n", len(posts))
 	fmt.Println("==================================")
 	fmt.Println()
 
-	// Print each post
+	// Print each post, with descriptions rendered to fit the terminal
+	width := render.TerminalWidth()
 	for i, post := range posts {
 		// Add a divider between posts except for the first one
 		if i > 0 {
 			fmt.Println("----------------------------------")
 		}
 
-		fmt.Printf("Title: %s\n", post.Title)
+		// A post is changed if the publisher edited it after the user last saw it
+		if *markChanged && post.SeenRevision.Valid && post.Revision > post.SeenRevision.Int32 {
+			fmt.Printf("Title: %s [UPDATED]\n", post.Title)
+		} else {
+			fmt.Printf("Title: %s\n", post.Title)
+		}
+		fmt.Printf("ID: %s\n", post.ID)
 		fmt.Printf("Feed: %s\n", post.FeedName)
 
+		if len(authors[post.ID]) > 0 {
+			fmt.Printf("Authors: %s\n", strings.Join(authors[post.ID], ", "))
+		}
+
 		if post.PublishedAt.Valid {
 			fmt.Printf("Published: %s\n", formatTime(post.PublishedAt.Time))
 		}
 
+		if post.Episode.Valid {
+			fmt.Printf("Episode: %d\n", post.Episode.Int32)
+		}
+
+		if len(categories[post.ID]) > 0 {
+			fmt.Printf("Categories: %s\n", strings.Join(categories[post.ID], ", "))
+		}
+
 		fmt.Printf("URL: %s\n", post.Url)
 
 		if post.Description.Valid && post.Description.String != "" {
-			// Print first 200 chars of description or less
-			desc := post.Description.String
-			if len(desc) > 200 {
-				desc = desc[:197] + "..."
+			if desc := render.Render(post.Description.String, width); desc != "" {
+				fmt.Printf("Description:\n%s\n", desc)
+			}
+		}
+
+		if len(attachments[post.ID]) > 0 {
+			fmt.Println("Attachments:")
+			for _, enclosure := range attachments[post.ID] {
+				fmt.Printf("  • %s\n", formatEnclosure(enclosure))
 			}
-			fmt.Printf("Description: %s\n", desc)
 		}
 
 		fmt.Println()
+
+		// Remember which revision the user has now seen
+		err := s.Db.MarkPostSeen(ctx, database.MarkPostSeenParams{
+			UserID:       user.ID,
+			PostID:       post.ID,
+			SeenRevision: post.Revision,
+			SeenAt:       time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark post as seen: %w", err)
+		}
 	}
 
 	return nil
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/download"
+	"github.com/google/uuid"
+)
+
+// Download statuses stored in the downloads table
+const (
+	downloadQueued      = "queued"
+	downloadDownloading = "downloading"
+	downloadDone        = "done"
+	downloadFailed      = "failed"
+	downloadDeleted     = "deleted"
+)
+
+// HandlerDownload handles the download command which queues, fetches and prunes podcast episodes
+func HandlerDownload(s *app.State, cmd app.Command, user database.User) error {
+	if len(cmd.Args) < 1 {
+		return errors.New("download command requires a subcommand: queue, run, list or keep")
+	}
+
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "queue":
+		return downloadQueue(s, args, user)
+	case "run":
+		return downloadRun(s, user)
+	case "list":
+		return downloadList(s, args, user)
+	case "keep":
+		return downloadKeep(s, args, user)
+	default:
+		return fmt.Errorf("unknown download subcommand: %s", cmd.Args[0])
+	}
+}
+
+// downloadQueue queues the episodes of followed feeds that have not been queued yet,
+// limited to the newest keep_episodes of each feed when a limit is set
+func downloadQueue(s *app.State, args []string, user database.User) error {
+	ctx := context.Background()
+
+	// Optionally restrict to a single feed
+	var feedID uuid.NullUUID
+	if len(args) > 0 {
+		feed, err := getFeedByURL(ctx, s, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to find feed with URL %s: %w", args[0], err)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	enclosureIDs, err := s.Db.GetDownloadCandidates(ctx, database.GetDownloadCandidatesParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get episodes to download: %w", err)
+	}
+
+	queued := 0
+	for _, enclosureID := range enclosureIDs {
+		now := time.Now()
+		n, err := s.Db.CreateDownload(ctx, database.CreateDownloadParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			UserID:      user.ID,
+			EnclosureID: enclosureID,
+			Status:      downloadQueued,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to queue download: %w", err)
+		}
+		queued += int(n)
+	}
+
+	fmt.Printf("Queued %d episode(s)\n", queued)
+	return nil
+}
+
+// downloadRun prunes episodes beyond each feed's retention limit and then fetches everything
+// still pending, resuming partial files left by earlier runs
+func downloadRun(s *app.State, user database.User) error {
+	// Stop cleanly on Ctrl+C; partial files are kept and resumed next time
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := pruneDownloads(ctx, s, user); err != nil {
+		return err
+	}
+
+	downloads, err := s.Db.GetDownloadsForUser(ctx, database.GetDownloadsForUserParams{UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("failed to get downloads: %w", err)
+	}
+
+	fetched, failed := 0, 0
+	for _, d := range downloads {
+		if d.Status == downloadDone || d.Status == downloadDeleted {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		dir := filepath.Join(s.Cfg.DownloadDir, download.SafeName(d.FeedName))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create download directory: %w", err)
+		}
+		dest := filepath.Join(dir, download.FileName(d.ID.String(), d.Url))
+
+		fmt.Printf("Downloading %s: %s\n", d.FeedName, d.PostTitle)
+		prev := download.Progress{
+			BytesDownloaded: d.BytesDownloaded,
+			ETag:            d.Etag.String,
+			LastModified:    d.LastModified.String,
+		}
+		if err := updateDownload(s, d.ID, downloadDownloading, dest, prev, nil); err != nil {
+			return err
+		}
+
+		progress, fetchErr := download.Fetch(ctx, s.Fetcher.TransferClient(), s.Fetcher.UserAgent(), d.Url, dest, prev)
+		status := downloadDone
+		if fetchErr != nil {
+			status = downloadFailed
+			failed++
+			fmt.Printf("  failed: %v\n", fetchErr)
+		} else {
+			fetched++
+			fmt.Printf("  saved %s (%s)\n", dest, formatBytes(progress.BytesDownloaded))
+		}
+
+		if err := updateDownload(s, d.ID, status, dest, progress, fetchErr); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Downloaded %d episode(s), %d failed\n", fetched, failed)
+	return nil
+}
+
+// pruneDownloads deletes the files of episodes that fall outside their feed's retention limit
+func pruneDownloads(ctx context.Context, s *app.State, user database.User) error {
+	expired, err := s.Db.GetExpiredDownloads(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get expired downloads: %w", err)
+	}
+
+	for _, d := range expired {
+		if d.FilePath.Valid {
+			for _, path := range []string{d.FilePath.String, d.FilePath.String + ".part"} {
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return fmt.Errorf("failed to delete %s: %w", path, err)
+				}
+			}
+			fmt.Printf("Deleted %s\n", d.FilePath.String)
+		}
+
+		if err := updateDownload(s, d.ID, downloadDeleted, d.FilePath.String, download.Progress{}, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateDownload records the status and progress of a download. It uses a fresh context so
+// progress is still saved after the run has been interrupted.
+func updateDownload(s *app.State, id uuid.UUID, status, filePath string, progress download.Progress, downloadErr error) error {
+	params := database.UpdateDownloadParams{
+		ID:              id,
+		Status:          status,
+		FilePath:        sql.NullString{String: filePath, Valid: filePath != ""},
+		BytesDownloaded: progress.BytesDownloaded,
+		TotalBytes:      sql.NullInt64{Int64: progress.TotalBytes, Valid: progress.TotalBytes > 0},
+		Etag:            sql.NullString{String: progress.ETag, Valid: progress.ETag != ""},
+		LastModified:    sql.NullString{String: progress.LastModified, Valid: progress.LastModified != ""},
+	}
+	if downloadErr != nil {
+		params.LastError = sql.NullString{String: downloadErr.Error(), Valid: true}
+	}
+
+	if err := s.Db.UpdateDownload(context.Background(), params); err != nil {
+		return fmt.Errorf("failed to update download: %w", err)
+	}
+	return nil
+}
+
+// downloadList prints the user's downloads, optionally filtered by status
+func downloadList(s *app.State, args []string, user database.User) error {
+	fs := flag.NewFlagSet("download list", flag.ContinueOnError)
+	status := fs.String("status", "", "only show downloads with this status (queued, downloading, done, failed, deleted)")
+	if _, err := parseFlags(fs, args); err != nil {
+		return fmt.Errorf("invalid download list arguments: %w", err)
+	}
+
+	ctx := context.Background()
+	downloads, err := s.Db.GetDownloadsForUser(ctx, database.GetDownloadsForUserParams{
+		UserID: user.ID,
+		Status: sql.NullString{String: *status, Valid: *status != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get downloads: %w", err)
+	}
+
+	if len(downloads) == 0 {
+		fmt.Println("No downloads found.")
+		return nil
+	}
+
+	for _, d := range downloads {
+		progress := formatBytes(d.BytesDownloaded)
+		if d.TotalBytes.Valid {
+			progress += " / " + formatBytes(d.TotalBytes.Int64)
+		}
+
+		fmt.Printf("[%s] %s: %s\n", d.Status, d.FeedName, d.PostTitle)
+		fmt.Printf("  URL: %s\n", d.Url)
+		fmt.Printf("  Progress: %s\n", progress)
+		if d.FilePath.Valid {
+			fmt.Printf("  File: %s\n", d.FilePath.String)
+		}
+		if d.Status == downloadFailed && d.LastError.Valid {
+			fmt.Printf("  Error: %s\n", d.LastError.String)
+		}
+	}
+
+	return nil
+}
+
+// downloadKeep sets how many of a feed's newest episodes are kept on disk
+func downloadKeep(s *app.State, args []string, user database.User) error {
+	if len(args) < 2 {
+		return errors.New("download keep requires a feed url and a number of episodes or \"all\"")
+	}
+
+	var keep sql.NullInt32
+	if args[1] != "all" {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of episodes: %s", args[1])
+		}
+		keep = sql.NullInt32{Int32: int32(n), Valid: true}
+	}
+
+	ctx := context.Background()
+	feed, err := getFeedByURL(ctx, s, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to find feed with URL %s: %w", args[0], err)
+	}
+
+	if err := s.Db.SetFeedFollowKeepEpisodes(ctx, database.SetFeedFollowKeepEpisodesParams{
+		UserID:       user.ID,
+		FeedID:       feed.ID,
+		KeepEpisodes: keep,
+	}); err != nil {
+		return fmt.Errorf("failed to set retention: %w", err)
+	}
+
+	if keep.Valid {
+		fmt.Printf("Keeping the newest %d episode(s) of %s\n", keep.Int32, feed.Name)
+	} else {
+		fmt.Printf("Keeping all episodes of %s\n", feed.Name)
+	}
+	return nil
+}
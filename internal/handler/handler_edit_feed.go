@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/feed"
+)
+
+// HandlerEditFeed handles the editfeed command which changes the name, proxy and request headers
+// of a feed. Only the user who added the feed may edit it.
+func HandlerEditFeed(s *app.State, cmd app.Command, user database.User) error {
+	fs := flag.NewFlagSet("editfeed", flag.ContinueOnError)
+	name := fs.String("name", "", "rename the feed")
+	proxy := fs.String("proxy", "", "fetch the feed through an http://, https:// or socks5:// proxy")
+	noProxy := fs.Bool("no-proxy", false, "use the configured proxy again")
+	clearHeaders := fs.Bool("clear-headers", false, "remove all stored request headers and credentials first")
+	auth := addAuthFlags(fs)
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("invalid editfeed flags: %w", err)
+	}
+	if len(args) < 1 {
+		return errors.New("editfeed command requires a url argument")
+	}
+	if *proxy != "" && *noProxy {
+		return errors.New("use either -proxy or -no-proxy, not both")
+	}
+
+	ctx := context.Background()
+	feedItem, err := getFeedByURL(ctx, s, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to find feed with URL %s: %w", args[0], err)
+	}
+	if feedItem.UserID != user.ID {
+		return fmt.Errorf("feed %s was added by another user", feedItem.Name)
+	}
+
+	params := database.UpdateFeedSettingsParams{
+		ID:             feedItem.ID,
+		Name:           feedItem.Name,
+		ProxyUrl:       feedItem.ProxyUrl,
+		RequestHeaders: feedItem.RequestHeaders,
+	}
+
+	if *name = strings.TrimSpace(*name); *name != "" {
+		params.Name = *name
+	}
+
+	switch {
+	case *noProxy:
+		params.ProxyUrl = sql.NullString{}
+	case *proxy != "":
+		if _, err := feed.ParseProxy(*proxy); err != nil {
+			return err
+		}
+		params.ProxyUrl = sql.NullString{String: *proxy, Valid: true}
+	}
+
+	// Only touch the stored headers when asked to, so editing the name works without the secret key
+	if *clearHeaders {
+		params.RequestHeaders = nil
+	}
+	if auth.basicAuth != "" || auth.bearer != "" || len(auth.headers) > 0 {
+		headers, err := feedHeaders(s, database.Feed{Name: params.Name, RequestHeaders: params.RequestHeaders})
+		if err != nil {
+			return err
+		}
+		if err := auth.apply(headers); err != nil {
+			return err
+		}
+		if params.RequestHeaders, err = encryptHeaders(s, headers); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Db.UpdateFeedSettings(ctx, params); err != nil {
+		return fmt.Errorf("failed to update feed: %w", err)
+	}
+
+	fmt.Printf("Feed updated: %s\n", params.Name)
+	fmt.Printf("URL: %s\n", feedItem.Url)
+	if params.ProxyUrl.Valid {
+		fmt.Printf("Proxy: %s\n", params.ProxyUrl.String)
+	}
+	if len(params.RequestHeaders) > 0 && s.SecretKey != nil {
+		// Show which headers are stored but never their values
+		headers, err := feedHeaders(s, database.Feed{Name: params.Name, RequestHeaders: params.RequestHeaders})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Request headers: %s\n", strings.Join(headerNames(headers), ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Skufu/RSS/internal/app"
+)
+
+// HandlerEnableFeed handles the enablefeed command which re-enables a feed disabled after repeated failures
+func HandlerEnableFeed(s *app.State, cmd app.Command) error {
+	// Check if we have the right number of arguments
+	if len(cmd.Args) < 1 {
+		return errors.New("enablefeed command requires a url argument")
+	}
+
+	url := cmd.Args[0]
+
+	// Get the feed by URL
+	ctx := context.Background()
+	feed, err := getFeedByURL(ctx, s, url)
+	if err != nil {
+		return fmt.Errorf("failed to find feed with URL %s: %w", url, err)
+	}
+
+	// Clear the failure state so the aggregator picks it up on its next tick
+	if err := s.Db.EnableFeed(ctx, feed.ID); err != nil {
+		return fmt.Errorf("failed to enable feed: %w", err)
+	}
+
+	fmt.Printf("Feed enabled: %s\n", feed.Name)
+	if feed.LastError.Valid {
+		fmt.Printf("Last error: %s\n", feed.LastError.String)
+	}
+
+	return nil
+}
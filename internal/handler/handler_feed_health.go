@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/database"
+)
+
+// formatLastTime returns a human-readable timestamp, or "never" for a missing one
+func formatLastTime(t time.Time, valid bool) string {
+	if !valid {
+		return "never"
+	}
+	return t.Format("Jan 02, 2006 15:04")
+}
+
+// worseHealth reports whether feed a is in worse health than feed b:
+// disabled feeds first, then by consecutive failures, then by the oldest successful fetch
+func worseHealth(a, b database.GetFeedHealthRow) bool {
+	if a.DisabledAt.Valid != b.DisabledAt.Valid {
+		return a.DisabledAt.Valid
+	}
+	if a.ConsecutiveFailures != b.ConsecutiveFailures {
+		return a.ConsecutiveFailures > b.ConsecutiveFailures
+	}
+	if a.LastSuccessAt.Valid != b.LastSuccessAt.Valid {
+		return !a.LastSuccessAt.Valid
+	}
+	return a.LastSuccessAt.Time.Before(b.LastSuccessAt.Time)
+}
+
+// HandlerFeedHealth handles the feedhealth command which reports the fetch health of every feed
+func HandlerFeedHealth(s *app.State, cmd app.Command) error {
+	fs := flag.NewFlagSet("feedhealth", flag.ContinueOnError)
+	sortBy := fs.String("sort", "health", "sort order: health, name, failures or items")
+	brokenOnly := fs.Bool("broken", false, "only show disabled or failing feeds")
+
+	if _, err := parseFlags(fs, cmd.Args); err != nil {
+		return fmt.Errorf("invalid feedhealth flags: %w", err)
+	}
+
+	// Get the health records of all feeds from the database
+	ctx := context.Background()
+	feeds, err := s.Db.GetFeedHealth(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get feed health: %w", err)
+	}
+
+	if *brokenOnly {
+		broken := feeds[:0]
+		for _, f := range feeds {
+			if f.DisabledAt.Valid || f.ConsecutiveFailures > 0 {
+				broken = append(broken, f)
+			}
+		}
+		feeds = broken
+	}
+
+	// If no feeds, print a message
+	if len(feeds) == 0 {
+		fmt.Println("No feeds found.")
+		return nil
+	}
+
+	// Feeds come back sorted by name
+	switch *sortBy {
+	case "health":
+		sort.SliceStable(feeds, func(i, j int) bool { return worseHealth(feeds[i], feeds[j]) })
+	case "failures":
+		sort.SliceStable(feeds, func(i, j int) bool { return feeds[i].ConsecutiveFailures > feeds[j].ConsecutiveFailures })
+	case "items":
+		sort.SliceStable(feeds, func(i, j int) bool { return feeds[i].ItemsPerDay > feeds[j].ItemsPerDay })
+	case "name":
+	default:
+		return fmt.Errorf("unknown sort order: %s", *sortBy)
+	}
+
+	// Print header
+	fmt.Println("Feed health:")
+	fmt.Println("------------")
+
+	// Print each feed with its fetch statistics
+	for i, f := range feeds {
+		status := "OK"
+		switch {
+		case f.DisabledAt.Valid && f.LastStatusCode.Valid && f.LastStatusCode.Int32 == http.StatusGone:
+			status = "GONE since " + formatLastTime(f.DisabledAt.Time, true)
+		case f.DisabledAt.Valid:
+			status = "DISABLED since " + formatLastTime(f.DisabledAt.Time, true)
+		case f.ConsecutiveFailures > 0:
+			status = fmt.Sprintf("FAILING (%d in a row)", f.ConsecutiveFailures)
+		case !f.LastSuccessAt.Valid:
+			status = "NOT FETCHED YET"
+		}
+
+		fmt.Printf("%d. %s [%s]\n", i+1, f.Name, status)
+		fmt.Printf("   URL: %s\n", f.Url)
+		fmt.Printf("   Last fetch: %s\n", formatLastTime(f.LastFetchedAt.Time, f.LastFetchedAt.Valid))
+		fmt.Printf("   Last success: %s\n", formatLastTime(f.LastSuccessAt.Time, f.LastSuccessAt.Valid))
+		if f.LastStatusCode.Valid {
+			fmt.Printf("   HTTP status: %d\n", f.LastStatusCode.Int32)
+		}
+		if f.LastError.Valid {
+			// Network errors are usually on our side or transient, the others come from the publisher
+			switch f.LastErrorKind.String {
+			case errorKindNetwork:
+				fmt.Printf("   Last error (network, likely transient): %s\n", f.LastError.String)
+			case errorKindSize:
+				fmt.Printf("   Last error (feed too large, raise max_body_size to accept it): %s\n", f.LastError.String)
+			case errorKindHTTP, errorKindParse:
+				fmt.Printf("   Last error (publisher, %s): %s\n", f.LastErrorKind.String, f.LastError.String)
+			default:
+				fmt.Printf("   Last error: %s\n", f.LastError.String)
+			}
+		}
+		fmt.Printf("   Items: %d (%.1f/day)\n", f.ItemCount, f.ItemsPerDay)
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/render"
 )
 
 // HandlerFeeds handles the feeds command which lists all feeds in the database
@@ -30,6 +31,41 @@ func HandlerFeeds(s *app.State, cmd app.Command) error {
 	for i, feed := range feeds {
 		fmt.Printf("%d. %s\n", i+1, feed.Name)
 		fmt.Printf("   URL: %s\n", feed.Url)
+
+		// Channel details, known once the feed has been fetched
+		if feed.Title.Valid && feed.Title.String != feed.Name {
+			fmt.Printf("   Title: %s\n", feed.Title.String)
+		}
+		if feed.SiteUrl.Valid {
+			fmt.Printf("   Site: %s\n", feed.SiteUrl.String)
+		}
+		if feed.Description.Valid {
+			if desc := render.Excerpt(feed.Description.String, 200); desc != "" {
+				fmt.Printf("   Description: %s\n", desc)
+			}
+		}
+		if feed.Language.Valid {
+			fmt.Printf("   Language: %s\n", feed.Language.String)
+		}
+		if feed.ImageUrl.Valid {
+			fmt.Printf("   Image: %s\n", feed.ImageUrl.String)
+		}
+		if feed.Generator.Valid {
+			fmt.Printf("   Generator: %s\n", feed.Generator.String)
+		}
+		if !feed.LastSuccessAt.Valid {
+			fmt.Println("   (not fetched yet)")
+		}
+
+		// URLs the feed was permanently redirected away from
+		history, err := s.Db.GetFeedURLHistory(ctx, feed.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get URL history: %w", err)
+		}
+		for _, previous := range history {
+			fmt.Printf("   Moved from: %s (%s)\n", previous.Url, formatTime(previous.CreatedAt))
+		}
+
 		fmt.Printf("   Added by: %s\n", feed.UserName)
 		fmt.Println()
 	}
@@ -2,15 +2,56 @@ package handler
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Skufu/RSS/internal/app"
 	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/feed"
 	"github.com/google/uuid"
 )
 
+// findDiscoveredFeed looks for stored feeds among those linked from a web page,
+// asking the user to pick when several of them have been added
+func findDiscoveredFeed(ctx context.Context, s *app.State, pageURL string) (database.Feed, error) {
+	discovered, err := s.Fetcher.Discover(ctx, pageURL, feed.FetchOptions{})
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	var known []feed.DiscoveredFeed
+	feeds := make(map[string]database.Feed)
+	for _, d := range discovered {
+		stored, err := getFeedByURL(ctx, s, d.URL)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return database.Feed{}, err
+		}
+		if d.Title == "" {
+			d.Title = stored.Name
+		}
+		known = append(known, d)
+		feeds[d.URL] = stored
+	}
+
+	if len(known) == 0 {
+		if len(discovered) > 0 {
+			return database.Feed{}, fmt.Errorf("found %d feed(s) on the page but none has been added yet, use addfeed first", len(discovered))
+		}
+		return database.Feed{}, sql.ErrNoRows
+	}
+
+	chosen, err := chooseFeed(known)
+	if err != nil {
+		return database.Feed{}, err
+	}
+	return feeds[chosen.URL], nil
+}
+
 // HandlerFollow handles the follow command which creates a follow relationship between the current user and a feed
 func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 	// Check if we have the right number of arguments
@@ -20,9 +61,12 @@ func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 
 	url := cmd.Args[0]
 
-	// Get the feed by URL
+	// Get the feed by URL, falling back to the feeds linked from a web page
 	ctx := context.Background()
-	feed, err := s.Db.GetFeedByURL(ctx, url)
+	feedItem, err := getFeedByURL(ctx, s, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		feedItem, err = findDiscoveredFeed(ctx, s, url)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to find feed with URL %s: %w", url, err)
 	}
@@ -34,7 +78,7 @@ func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 		CreatedAt: now,
 		UpdatedAt: now,
 		UserID:    user.ID,
-		FeedID:    feed.ID,
+		FeedID:    feedItem.ID,
 	}
 
 	feedFollow, err := s.Db.CreateFeedFollow(ctx, feedFollowParams)
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/feed"
+)
+
+// HandlerMergeFeeds handles the mergefeeds command which merges feeds whose URLs differ only in
+// spelling into the oldest of them, moving their follows and posts, and canonicalizes every feed URL
+func HandlerMergeFeeds(s *app.State, cmd app.Command) error {
+	fs := flag.NewFlagSet("mergefeeds", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only report the duplicates")
+
+	if _, err := parseFlags(fs, cmd.Args); err != nil {
+		return fmt.Errorf("invalid mergefeeds flags: %w", err)
+	}
+
+	ctx := context.Background()
+	feeds, err := s.Db.GetFeedURLs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %w", err)
+	}
+
+	// Group the feeds by canonical URL, oldest first
+	var keys []string
+	groups := make(map[string][]database.GetFeedURLsRow)
+	for _, f := range feeds {
+		key := feed.CanonicalURL(f.Url)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], f)
+	}
+
+	merged, updated := 0, 0
+	for _, key := range keys {
+		keep, duplicates := groups[key][0], groups[key][1:]
+
+		// Fold the duplicates into the oldest feed
+		for _, dup := range duplicates {
+			fmt.Printf("Merging %s (%s) into %s (%s)\n", dup.Name, dup.Url, keep.Name, keep.Url)
+			merged++
+			if *dryRun {
+				continue
+			}
+			if err := mergeFeed(ctx, s, dup, keep); err != nil {
+				return err
+			}
+		}
+
+		// Store the normalized URL and its key
+		url := feed.NormalizeURL(keep.Url)
+		if url == keep.Url && keep.CanonicalUrl.Valid && keep.CanonicalUrl.String == key {
+			continue
+		}
+		updated++
+		if *dryRun {
+			continue
+		}
+		err := s.Db.UpdateFeedURL(ctx, database.UpdateFeedURLParams{
+			ID:           keep.ID,
+			Url:          url,
+			CanonicalUrl: canonicalURL(url),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update URL of feed %s: %w", keep.Name, err)
+		}
+	}
+
+	if *dryRun {
+		fmt.Printf("Would merge %d duplicate feed(s) and canonicalize %d URL(s)\n", merged, updated)
+	} else {
+		fmt.Printf("Merged %d duplicate feed(s) and canonicalized %d URL(s)\n", merged, updated)
+	}
+	return nil
+}
+
+// mergeFeed moves the follows, posts and previous URLs of a duplicate feed to the feed it
+// duplicates and deletes it, all in one transaction. Posts the kept feed already has are dropped
+// along with the duplicate once their views, enclosures and downloads are moved to the kept copies.
+func mergeFeed(ctx context.Context, s *app.State, dup, keep database.GetFeedURLsRow) error {
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start merging feed %s: %w", dup.Name, err)
+	}
+	defer tx.Rollback()
+	q := s.Db.WithTx(tx)
+
+	err = q.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{
+		ToFeedID:   keep.ID,
+		FromFeedID: dup.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move follows of feed %s: %w", dup.Name, err)
+	}
+
+	err = q.MoveFeedPosts(ctx, database.MoveFeedPostsParams{
+		ToFeedID:   keep.ID,
+		FromFeedID: dup.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move posts of feed %s: %w", dup.Name, err)
+	}
+
+	// The posts left behind are duplicates; keep what users did with them
+	err = q.MoveDuplicatePostViews(ctx, database.MoveDuplicatePostViewsParams{
+		ToFeedID:   keep.ID,
+		FromFeedID: dup.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move post views of feed %s: %w", dup.Name, err)
+	}
+
+	err = q.MoveDuplicatePostEnclosures(ctx, database.MoveDuplicatePostEnclosuresParams{
+		ToFeedID:   keep.ID,
+		FromFeedID: dup.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move enclosures of feed %s: %w", dup.Name, err)
+	}
+
+	err = q.MoveDuplicatePostDownloads(ctx, database.MoveDuplicatePostDownloadsParams{
+		ToFeedID:   keep.ID,
+		FromFeedID: dup.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move downloads of feed %s: %w", dup.Name, err)
+	}
+
+	// Downloads still left are episodes the user also downloaded from the kept feed
+	files, err := q.GetFeedDownloadFiles(ctx, dup.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get downloads of feed %s: %w", dup.Name, err)
+	}
+
+	err = q.MoveFeedURLHistory(ctx, database.MoveFeedURLHistoryParams{
+		ToFeedID:   keep.ID,
+		FromFeedID: dup.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move URL history of feed %s: %w", dup.Name, err)
+	}
+
+	if err := q.DeleteFeed(ctx, dup.ID); err != nil {
+		return fmt.Errorf("failed to delete feed %s: %w", dup.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to merge feed %s: %w", dup.Name, err)
+	}
+
+	// Their rows are gone, so remove the files too
+	for _, file := range files {
+		for _, path := range []string{file.String, file.String + ".part"} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Printf("Warning: failed to delete %s: %v\n", path, err)
+			}
+		}
+		fmt.Printf("Deleted duplicate download %s\n", file.String)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Skufu/RSS/internal/app"
+	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/render"
+	"github.com/google/uuid"
+)
+
+// HandlerRead handles the read command which displays the full content of a post
+func HandlerRead(s *app.State, cmd app.Command, user database.User) error {
+	// Check if we have the right number of arguments
+	if len(cmd.Args) < 1 {
+		return errors.New("read command requires a post id argument")
+	}
+
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %w", err)
+	}
+
+	// Get the post, as long as it is from a feed the user follows
+	ctx := context.Background()
+	post, err := s.Db.GetPostForUser(ctx, database.GetPostForUserParams{
+		ID:     postID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no post %s in the feeds you follow", postID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find post %s: %w", postID, err)
+	}
+
+	// Print the post header
+	fmt.Printf("Title: %s\n", post.Title)
+	fmt.Printf("Feed: %s\n", post.FeedName)
+	if post.PublishedAt.Valid {
+		fmt.Printf("Published: %s\n", formatTime(post.PublishedAt.Time))
+	}
+	fmt.Printf("URL: %s\n", post.Url)
+	fmt.Println("==================================")
+	fmt.Println()
+
+	// Prefer the full content, falling back to the description
+	body := post.Content.String
+	if body == "" {
+		body = post.Description.String
+	}
+	if body == "" {
+		fmt.Println("This post has no stored content. Open the URL above to read it.")
+	} else {
+		fmt.Println(render.Render(body, render.TerminalWidth()))
+	}
+
+	// Remember which revision the user has now seen
+	err = s.Db.MarkPostSeen(ctx, database.MarkPostSeenParams{
+		UserID:       user.ID,
+		PostID:       post.ID,
+		SeenRevision: post.Revision,
+		SeenAt:       time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark post as seen: %w", err)
+	}
+
+	return nil
+}
@@ -20,7 +20,7 @@ func HandlerUnfollow(s *app.State, cmd app.Command, user database.User) error {
 
 	// Verify the feed exists
 	ctx := context.Background()
-	feed, err := s.Db.GetFeedByURL(ctx, url)
+	feed, err := getFeedByURL(ctx, s, url)
 	if err != nil {
 		return fmt.Errorf("failed to find feed with URL %s: %w", url, err)
 	}
@@ -28,7 +28,7 @@ func HandlerUnfollow(s *app.State, cmd app.Command, user database.User) error {
 	// Delete the feed follow record
 	err = s.Db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
 		UserID: user.ID,
-		Url:    url,
+		FeedID: feed.ID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to unfollow feed: %w", err)
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Skufu/RSS/internal/config"
+	"github.com/Skufu/RSS/internal/feed"
+)
+
+// Bounds of the retry delay applied to feeds that fail to fetch
+const (
+	failureBackoffBase = time.Minute
+	failureBackoffMax  = 24 * time.Hour
+)
+
+// failureBackoff returns how long to wait before retrying a feed after its nth consecutive failure,
+// doubling with every failure up to failureBackoffMax
+func failureBackoff(failures int32) time.Duration {
+	delay := failureBackoffBase
+	for i := int32(1); i < failures; i++ {
+		delay *= 2
+		if delay >= failureBackoffMax {
+			return failureBackoffMax
+		}
+	}
+	return delay
+}
+
+// observedItemSample is the number of most recent dated items used to estimate a feed's posting frequency
+const observedItemSample = 10
+
+// refreshBounds clamps the adaptive refresh interval of every feed
+type refreshBounds struct {
+	min time.Duration
+	max time.Duration
+}
+
+// parseRefreshBounds reads the refresh interval bounds from the config
+func parseRefreshBounds(cfg *config.Config) (refreshBounds, error) {
+	minInterval, err := time.ParseDuration(cfg.MinFetchInterval)
+	if err != nil {
+		return refreshBounds{}, fmt.Errorf("invalid min_fetch_interval: %w", err)
+	}
+	maxInterval, err := time.ParseDuration(cfg.MaxFetchInterval)
+	if err != nil {
+		return refreshBounds{}, fmt.Errorf("invalid max_fetch_interval: %w", err)
+	}
+	if minInterval <= 0 || maxInterval < minInterval {
+		return refreshBounds{}, errors.New("min_fetch_interval must be positive and not above max_fetch_interval")
+	}
+	return refreshBounds{min: minInterval, max: maxInterval}, nil
+}
+
+// observedInterval estimates how often a feed publishes from the average gap between
+// its most recent dated items, or returns zero when there are too few dates
+func observedInterval(items []feed.RSSItem) time.Duration {
+	var dates []time.Time
+	for _, item := range items {
+		if t, err := parsePublishedAt(item.PubDate); err == nil {
+			dates = append(dates, t)
+		}
+	}
+	if len(dates) < 2 {
+		return 0
+	}
+
+	// Newest first, keeping only the recent items
+	sort.Slice(dates, func(i, j int) bool { return dates[i].After(dates[j]) })
+	if len(dates) > observedItemSample {
+		dates = dates[:observedItemSample]
+	}
+
+	return dates[0].Sub(dates[len(dates)-1]) / time.Duration(len(dates)-1)
+}
+
+// secondsUntil converts a due time into a delay from now, rounded up. Due times are stored as
+// NOW() plus this delay so they are measured on the database clock and in its time zone,
+// whatever the time zone of the machine running the aggregator.
+func secondsUntil(now, t time.Time) int32 {
+	return int32(math.Ceil(t.Sub(now).Seconds()))
+}
+
+// nextFetchInterval picks how long to wait before fetching a feed again. It aims to check
+// twice per observed posting gap, never sooner than the publisher asks through <ttl>,
+// <sy:updatePeriod> or cache headers, and always within the configured bounds.
+// Responses without a body (304) keep the previous interval.
+func nextFetchInterval(result *feed.FetchResult, previous time.Duration, bounds refreshBounds) time.Duration {
+	interval := previous
+	if result.Feed != nil {
+		if observed := observedInterval(result.Feed.Channel.Item); observed > 0 {
+			interval = observed / 2
+		}
+		interval = max(interval, result.Feed.UpdateInterval())
+	}
+	interval = max(interval, result.CacheLifetime)
+
+	return min(max(interval, bounds.min), bounds.max)
+}
+
+// skipSchedule holds the quiet windows a feed declares through <skipHours> and <skipDays>, in GMT
+type skipSchedule struct {
+	hours map[int]bool
+	days  map[time.Weekday]bool
+}
+
+// newSkipSchedule builds a skipSchedule from the hours and day names stored on a feed
+func newSkipSchedule(hours []int32, days []string) skipSchedule {
+	skip := skipSchedule{
+		hours: make(map[int]bool),
+		days:  make(map[time.Weekday]bool),
+	}
+	for _, hour := range hours {
+		skip.hours[int(hour)] = true
+	}
+	for _, name := range days {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if day.String() == name {
+				skip.days[day] = true
+			}
+		}
+	}
+	return skip
+}
+
+// adjust moves t forward to the start of the first hour outside the quiet windows.
+// A schedule that skips every hour of the week is ignored.
+func (skip skipSchedule) adjust(t time.Time) time.Time {
+	candidate := t.UTC()
+	for i := 0; i < 7*24; i++ {
+		if !skip.hours[candidate.Hour()] && !skip.days[candidate.Weekday()] {
+			return candidate.In(t.Location())
+		}
+		candidate = candidate.Truncate(time.Hour).Add(time.Hour)
+	}
+	return t
+}
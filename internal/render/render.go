@@ -0,0 +1,337 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// defaultWidth is the wrap width used when the terminal width is unknown
+const defaultWidth = 80
+
+// blockElements are the HTML elements that start a new block when rendered as text
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"blockquote": true, "pre": true, "figure": true, "figcaption": true,
+	"table": true, "tr": true, "hr": true,
+}
+
+// TerminalWidth returns the width of the terminal on stdout, falling back to $COLUMNS when
+// stdout is not a terminal and to 80 columns when neither is known
+func TerminalWidth() int {
+	if columns := ttyWidth(); columns > 20 {
+		return columns
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 20 {
+		return columns
+	}
+	return defaultWidth
+}
+
+// list tracks the state of an open <ul> or <ol>
+type list struct {
+	ordered bool
+	next    int
+}
+
+// renderer converts a stream of HTML tokens into wrapped text
+type renderer struct {
+	width int
+	out   strings.Builder
+
+	inline   strings.Builder // text of the block being collected
+	marker   string          // list bullet for the first line of the block being collected
+	lists    []list
+	quotes   int
+	pre      int
+	skip     int
+	links    []string
+	hrefs    []string // hrefs of the open <a> elements
+	lastItem bool     // whether the last written block was a list item
+}
+
+// Render converts HTML to plain text wrapped to width: links become numbered references
+// listed at the end, lists get bullets or numbers, blockquotes are indented, and scripts
+// and styles are dropped
+func Render(htmlText string, width int) string {
+	r := &renderer{width: width}
+	z := html.NewTokenizer(strings.NewReader(htmlText))
+	for z.Next() != html.ErrorToken {
+		r.token(z.Token())
+	}
+	r.flush(false)
+
+	if len(r.links) > 0 {
+		r.out.WriteString("\n\nReferences:\n")
+		for i, link := range r.links {
+			fmt.Fprintf(&r.out, "[%d] %s\n", i+1, link)
+		}
+	}
+
+	return strings.TrimRight(r.out.String(), "\n")
+}
+
+// Excerpt converts HTML to a single line of plain text of at most maxRunes characters,
+// cutting at a word boundary
+func Excerpt(htmlText string, maxRunes int) string {
+	var words []string
+	skip := 0
+	z := html.NewTokenizer(strings.NewReader(htmlText))
+	for z.Next() != html.ErrorToken {
+		token := z.Token()
+		switch token.Type {
+		case html.StartTagToken:
+			if token.Data == "script" || token.Data == "style" {
+				skip++
+			}
+		case html.EndTagToken:
+			if (token.Data == "script" || token.Data == "style") && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				words = append(words, strings.Fields(token.Data)...)
+			}
+		}
+	}
+
+	text := strings.Join(words, " ")
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	if maxRunes <= 3 {
+		// No room for an ellipsis
+		return string([]rune(text)[:max(maxRunes, 0)])
+	}
+
+	// Cut at the last space that leaves room for the ellipsis
+	runes := []rune(text)[:maxRunes-3]
+	cut := string(runes)
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return cut + "..."
+}
+
+// token handles a single HTML token
+func (r *renderer) token(token html.Token) {
+	switch token.Type {
+	case html.TextToken:
+		if r.skip == 0 {
+			r.inline.WriteString(token.Data)
+		}
+	case html.StartTagToken, html.SelfClosingTagToken:
+		r.start(token)
+		if token.Type == html.SelfClosingTagToken {
+			r.end(token.Data)
+		}
+	case html.EndTagToken:
+		r.end(token.Data)
+	}
+}
+
+// start handles an opening tag
+func (r *renderer) start(token html.Token) {
+	if r.skip > 0 {
+		if token.Data == "script" || token.Data == "style" {
+			r.skip++
+		}
+		return
+	}
+
+	switch token.Data {
+	case "script", "style", "head":
+		r.skip++
+	case "br":
+		r.inline.WriteString("\n")
+	case "hr":
+		r.flush(false)
+		r.write(strings.Repeat("─", min(r.width, 40)), false)
+	case "img":
+		if alt := strings.TrimSpace(attr(token, "alt")); alt != "" {
+			r.inline.WriteString(" [image: " + alt + "] ")
+		}
+	case "a":
+		r.hrefs = append(r.hrefs, attr(token, "href"))
+	case "ul", "ol":
+		r.flush(false)
+		r.lists = append(r.lists, list{ordered: token.Data == "ol", next: 1})
+	case "li":
+		r.flush(false)
+		r.marker = "• "
+		if n := len(r.lists); n > 0 && r.lists[n-1].ordered {
+			r.marker = fmt.Sprintf("%d. ", r.lists[n-1].next)
+			r.lists[n-1].next++
+		}
+	case "blockquote":
+		r.flush(false)
+		r.quotes++
+	case "pre":
+		r.flush(false)
+		r.pre++
+	case "td", "th":
+		r.inline.WriteString(" ")
+	default:
+		if blockElements[token.Data] {
+			r.flush(false)
+		}
+	}
+}
+
+// end handles a closing tag
+func (r *renderer) end(name string) {
+	if r.skip > 0 {
+		if name == "script" || name == "style" || name == "head" {
+			r.skip--
+		}
+		return
+	}
+
+	switch name {
+	case "a":
+		if n := len(r.hrefs); n > 0 {
+			href := r.hrefs[n-1]
+			r.hrefs = r.hrefs[:n-1]
+			if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "javascript:") {
+				r.links = append(r.links, href)
+				fmt.Fprintf(&r.inline, "[%d]", len(r.links))
+			}
+		}
+	case "ul", "ol":
+		r.flush(false)
+		if n := len(r.lists); n > 0 {
+			r.lists = r.lists[:n-1]
+		}
+	case "li":
+		r.flush(false)
+	case "blockquote":
+		r.flush(false)
+		if r.quotes > 0 {
+			r.quotes--
+		}
+	case "pre":
+		r.flush(true)
+		if r.pre > 0 {
+			r.pre--
+		}
+	case "td", "th":
+		r.inline.WriteString(" ")
+	default:
+		if blockElements[name] {
+			r.flush(false)
+		}
+	}
+}
+
+// flush writes the collected block, keeping its whitespace when it is preformatted
+func (r *renderer) flush(preformatted bool) {
+	text := r.inline.String()
+	r.inline.Reset()
+
+	if r.pre > 0 || preformatted {
+		text = strings.Trim(text, "\n")
+		if strings.TrimSpace(text) != "" {
+			r.write(text, false)
+		}
+		return
+	}
+
+	// Collapse whitespace within each hard line break
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	r.write(strings.Join(lines, "\n"), true)
+}
+
+// write adds a block to the output, indented for the open lists and blockquotes
+func (r *renderer) write(text string, wrap bool) {
+	isItem := r.marker != ""
+
+	// Separate blocks with a blank line, except between items of the same list
+	if r.out.Len() > 0 {
+		if isItem && r.lastItem {
+			r.out.WriteString("\n")
+		} else {
+			r.out.WriteString("\n\n")
+		}
+	}
+	r.lastItem = isItem
+
+	indent := strings.Repeat("> ", r.quotes)
+	if len(r.lists) > 1 {
+		indent += strings.Repeat("  ", len(r.lists)-1)
+	}
+	first := indent + r.marker
+	rest := indent + strings.Repeat(" ", utf8.RuneCountInString(r.marker))
+	r.marker = ""
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if wrap {
+			lines = append(lines, wrapLine(line, r.width-utf8.RuneCountInString(rest))...)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+
+	for i, line := range lines {
+		if i > 0 {
+			r.out.WriteString("\n")
+			r.out.WriteString(rest)
+		} else {
+			r.out.WriteString(first)
+		}
+		r.out.WriteString(line)
+	}
+}
+
+// attr returns the value of a tag attribute, or "" when it is missing
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// wrapLine splits a line into lines of at most width characters, breaking between words.
+// Words longer than the width get a line of their own.
+func wrapLine(line string, width int) []string {
+	width = max(width, 20)
+
+	var lines []string
+	var current strings.Builder
+	currentLen := 0
+	for _, word := range strings.Fields(line) {
+		wordLen := utf8.RuneCountInString(word)
+		if currentLen > 0 && currentLen+1+wordLen > width {
+			lines = append(lines, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+		if currentLen > 0 {
+			current.WriteString(" ")
+			currentLen++
+		}
+		current.WriteString(word)
+		currentLen += wordLen
+	}
+	if currentLen > 0 {
+		lines = append(lines, current.String())
+	}
+	return lines
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd)
+
+package render
+
+// ttyWidth is not supported on this platform, so the width comes from $COLUMNS
+func ttyWidth() int {
+	return 0
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || netbsd
+
+package render
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize mirrors struct winsize from <sys/ioctl.h>
+type winsize struct {
+	Row, Col, Xpixel, Ypixel uint16
+}
+
+// ttyWidth asks the terminal on stdout for its width, returning 0 when stdout is not a terminal
+func ttyWidth() int {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
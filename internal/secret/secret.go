@@ -0,0 +1,79 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length in bytes of an AES-256 key
+const KeySize = 32
+
+// ErrNoKey is returned when a secret has to be encrypted or decrypted but no key is configured
+var ErrNoKey = errors.New("no secret_key set in the config")
+
+// Key encrypts values stored in the database with AES-256-GCM
+type Key struct {
+	aead cipher.AEAD
+}
+
+// ParseKey decodes a base64 encoded 32-byte key, such as the output of `openssl rand -base64 32`.
+// An empty string gives a nil key, which fails every operation with ErrNoKey.
+func ParseKey(encoded string) (*Key, error) {
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("secret key is not valid base64: %w", err)
+	}
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("secret key must be %d bytes, got %d", KeySize, len(raw))
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead}, nil
+}
+
+// Encrypt seals plaintext with a random nonce, which is prepended to the result
+func (k *Key) Encrypt(plaintext []byte) ([]byte, error) {
+	if k == nil {
+		return nil, ErrNoKey
+	}
+
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+	return k.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens a value produced by Encrypt, failing if it was encrypted with another key or tampered with
+func (k *Key) Decrypt(ciphertext []byte) ([]byte, error) {
+	if k == nil {
+		return nil, ErrNoKey
+	}
+
+	nonceSize := k.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("encrypted value is too short")
+	}
+	plaintext, err := k.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.New("error decrypting value: wrong secret key or corrupted data")
+	}
+	return plaintext, nil
+}
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/Skufu/RSS/internal/app"
 	"github.com/Skufu/RSS/internal/config"
 	"github.com/Skufu/RSS/internal/database"
+	"github.com/Skufu/RSS/internal/feed"
 	"github.com/Skufu/RSS/internal/handler"
+	"github.com/Skufu/RSS/internal/secret"
 	_ "github.com/lib/pq"
 )
 
@@ -36,10 +39,25 @@ func main() {
 	// Create database queries
 	dbQueries := database.New(db)
 
+	// Create the HTTP client shared by every command
+	fetcher, err := newFetcher(&cfg)
+	if err != nil {
+		log.Fatalf("Error configuring HTTP client: %v", err)
+	}
+
+	// Load the key that protects stored feed credentials
+	secretKey, err := secret.ParseKey(cfg.SecretKey)
+	if err != nil {
+		log.Fatalf("Error reading secret_key: %v", err)
+	}
+
 	// Initialize application state
 	s := &app.State{
-		Db:  dbQueries,
-		Cfg: &cfg,
+		Db:        dbQueries,
+		Conn:      db,
+		Cfg:       &cfg,
+		Fetcher:   fetcher,
+		SecretKey: secretKey,
 	}
 
 	// Initialize commands
@@ -54,10 +72,16 @@ func main() {
 	cmds.Register("agg", handler.HandlerAgg)
 	cmds.Register("addfeed", app.MiddlewareLoggedIn(handler.HandlerAddFeed))
 	cmds.Register("feeds", handler.HandlerFeeds)
+	cmds.Register("editfeed", app.MiddlewareLoggedIn(handler.HandlerEditFeed))
+	cmds.Register("enablefeed", handler.HandlerEnableFeed)
+	cmds.Register("feedhealth", handler.HandlerFeedHealth)
+	cmds.Register("mergefeeds", handler.HandlerMergeFeeds)
 	cmds.Register("follow", app.MiddlewareLoggedIn(handler.HandlerFollow))
 	cmds.Register("unfollow", app.MiddlewareLoggedIn(handler.HandlerUnfollow))
 	cmds.Register("following", app.MiddlewareLoggedIn(handler.HandlerFollowing))
 	cmds.Register("browse", app.MiddlewareLoggedIn(handler.HandlerBrowse))
+	cmds.Register("read", app.MiddlewareLoggedIn(handler.HandlerRead))
+	cmds.Register("download", app.MiddlewareLoggedIn(handler.HandlerDownload))
 
 	// Process command line arguments
 	args := os.Args
@@ -65,7 +89,7 @@ func main() {
 	if len(args) < 2 {
 		fmt.Println("Error: not enough arguments provided")
 		fmt.Println("Usage: gator <command> [args...]")
-		fmt.Println("Available commands: login, register, reset, users, agg, addfeed, feeds, follow, unfollow, following, browse")
+		fmt.Println("Available commands: login, register, reset, users, agg, addfeed, feeds, editfeed, enablefeed, feedhealth, mergefeeds, follow, unfollow, following, browse, read, download")
 		os.Exit(1)
 	}
 
@@ -81,3 +105,27 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// newFetcher builds the feed fetcher from the HTTP settings in the config
+func newFetcher(cfg *config.Config) (*feed.Fetcher, error) {
+	timeout, err := time.ParseDuration(cfg.FetchTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fetch_timeout: %w", err)
+	}
+	idleConnTimeout, err := time.ParseDuration(cfg.IdleConnTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid idle_conn_timeout: %w", err)
+	}
+
+	return feed.NewFetcher(feed.FetcherOptions{
+		Timeout:             timeout,
+		UserAgent:           cfg.UserAgent,
+		MaxBodySize:         cfg.MaxBodySize,
+		Proxy:               cfg.Proxy,
+		CABundle:            cfg.CABundle,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     idleConnTimeout,
+		DisableKeepAlives:   cfg.DisableKeepAlives,
+	})
+}
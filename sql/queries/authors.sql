@@ -0,0 +1,30 @@
+-- name: CreateAuthor :one
+INSERT INTO authors (id, created_at, name)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: AddPostAuthor :exec
+INSERT INTO post_authors (post_id, author_id, position)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (post_id, author_id) DO NOTHING;
+
+-- name: DeletePostAuthors :exec
+DELETE FROM post_authors
+WHERE post_id = $1;
+
+-- name: GetAuthorsForPosts :many
+SELECT pa.post_id, a.name
+FROM post_authors pa
+JOIN authors a ON pa.author_id = a.id
+WHERE pa.post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY pa.post_id, pa.position;
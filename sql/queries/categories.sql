@@ -0,0 +1,30 @@
+-- name: CreateCategory :one
+INSERT INTO categories (id, created_at, name)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: AddPostCategory :exec
+INSERT INTO post_categories (post_id, category_id, position)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (post_id, category_id) DO NOTHING;
+
+-- name: DeletePostCategories :exec
+DELETE FROM post_categories
+WHERE post_id = $1;
+
+-- name: GetCategoriesForPosts :many
+SELECT pc.post_id, c.name
+FROM post_categories pc
+JOIN categories c ON pc.category_id = c.id
+WHERE pc.post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY pc.post_id, pc.position;
@@ -0,0 +1,113 @@
+-- name: GetDownloadCandidates :many
+WITH ranked AS (
+    SELECT
+        e.id AS enclosure_id,
+        p.feed_id,
+        ff.keep_episodes,
+        DENSE_RANK() OVER (
+            PARTITION BY p.feed_id
+            ORDER BY COALESCE(p.published_at, p.created_at) DESC, p.id
+        ) AS episode_rank
+    FROM enclosures e
+    JOIN posts p ON e.post_id = p.id
+    JOIN feed_follows ff ON ff.feed_id = p.feed_id
+    WHERE ff.user_id = sqlc.arg(user_id)
+      AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id)::uuid)
+      AND (e.mime_type IS NULL OR e.mime_type LIKE 'audio/%' OR e.mime_type LIKE 'video/%')
+)
+SELECT ranked.enclosure_id
+FROM ranked
+LEFT JOIN downloads d ON d.enclosure_id = ranked.enclosure_id AND d.user_id = sqlc.arg(user_id)
+WHERE d.id IS NULL
+  AND (ranked.keep_episodes IS NULL OR ranked.episode_rank <= ranked.keep_episodes);
+
+-- name: CreateDownload :execrows
+INSERT INTO downloads (id, created_at, updated_at, user_id, enclosure_id, status)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (user_id, enclosure_id) DO NOTHING;
+
+-- name: GetDownloadsForUser :many
+SELECT
+    d.id,
+    d.status,
+    d.file_path,
+    d.bytes_downloaded,
+    d.total_bytes,
+    d.last_error,
+    d.etag,
+    d.last_modified,
+    d.updated_at,
+    e.url,
+    p.title AS post_title,
+    f.name AS feed_name
+FROM downloads d
+JOIN enclosures e ON d.enclosure_id = e.id
+JOIN posts p ON e.post_id = p.id
+JOIN feeds f ON p.feed_id = f.id
+WHERE d.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(status)::text IS NULL OR d.status = sqlc.narg(status)::text)
+ORDER BY f.name, COALESCE(p.published_at, p.created_at) DESC;
+
+-- name: UpdateDownload :exec
+UPDATE downloads
+SET status = $2,
+    file_path = $3,
+    bytes_downloaded = $4,
+    total_bytes = $5,
+    last_error = $6,
+    etag = $7,
+    last_modified = $8,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetExpiredDownloads :many
+WITH ranked AS (
+    SELECT
+        d.id,
+        d.file_path,
+        ff.keep_episodes,
+        DENSE_RANK() OVER (
+            PARTITION BY p.feed_id
+            ORDER BY COALESCE(p.published_at, p.created_at) DESC, p.id
+        ) AS episode_rank
+    FROM downloads d
+    JOIN enclosures e ON d.enclosure_id = e.id
+    JOIN posts p ON e.post_id = p.id
+    JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = d.user_id
+    WHERE d.user_id = $1
+      AND d.status <> 'deleted'
+)
+SELECT ranked.id, ranked.file_path
+FROM ranked
+WHERE ranked.keep_episodes IS NOT NULL
+  AND ranked.episode_rank > ranked.keep_episodes;
+
+-- name: MoveDuplicatePostDownloads :exec
+-- Moves downloads of enclosures that the other feed's copy of a post also has onto that copy,
+-- unless the user already downloaded it there
+UPDATE downloads
+SET enclosure_id = kept_enclosure.id,
+    updated_at = NOW()
+FROM enclosures dup_enclosure
+JOIN posts dup ON dup.id = dup_enclosure.post_id
+JOIN posts kept ON kept.guid = dup.guid AND kept.feed_id = sqlc.arg(to_feed_id)
+JOIN enclosures kept_enclosure ON kept_enclosure.post_id = kept.id AND kept_enclosure.url = dup_enclosure.url
+WHERE downloads.enclosure_id = dup_enclosure.id
+  AND dup.feed_id = sqlc.arg(from_feed_id)
+  AND NOT EXISTS (SELECT 1 FROM downloads other WHERE other.user_id = downloads.user_id AND other.enclosure_id = kept_enclosure.id);
+
+-- name: GetFeedDownloadFiles :many
+-- Files downloaded for the posts of a feed, whose rows go when the feed is deleted
+SELECT d.file_path
+FROM downloads d
+JOIN enclosures e ON d.enclosure_id = e.id
+JOIN posts p ON e.post_id = p.id
+WHERE p.feed_id = $1
+  AND d.file_path IS NOT NULL;
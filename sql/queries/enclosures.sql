@@ -0,0 +1,42 @@
+-- name: CreateEnclosure :exec
+INSERT INTO enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration_seconds)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT (post_id, url) DO UPDATE
+SET mime_type = EXCLUDED.mime_type,
+    length = EXCLUDED.length,
+    duration_seconds = EXCLUDED.duration_seconds,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: DeleteRemovedEnclosures :exec
+-- Deletes the enclosures a post no longer has, except downloaded ones whose files are still on disk
+DELETE FROM enclosures
+WHERE post_id = sqlc.arg(post_id)
+  AND NOT (url = ANY(sqlc.arg(urls)::text[]))
+  AND NOT EXISTS (SELECT 1 FROM downloads d WHERE d.enclosure_id = enclosures.id);
+
+-- name: GetEnclosuresForPosts :many
+SELECT *
+FROM enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY created_at, url;
+
+-- name: MoveDuplicatePostEnclosures :exec
+-- Moves the enclosures of posts a feed shares with another feed onto the other feed's copies,
+-- unless the copy already has them
+UPDATE enclosures
+SET post_id = kept.id,
+    updated_at = NOW()
+FROM posts dup
+JOIN posts kept ON kept.guid = dup.guid AND kept.feed_id = sqlc.arg(to_feed_id)
+WHERE enclosures.post_id = dup.id
+  AND dup.feed_id = sqlc.arg(from_feed_id)
+  AND NOT EXISTS (SELECT 1 FROM enclosures other WHERE other.post_id = kept.id AND other.url = enclosures.url);
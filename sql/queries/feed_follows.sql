@@ -34,12 +34,21 @@ WHERE ff.user_id = $1
 ORDER BY f.name;
 
 -- name: GetFeedByURL :one
+-- Feeds added before URLs were canonicalized only match their exact URL.
+-- Feeds that moved are also found by their previous URLs.
 SELECT * FROM feeds
-WHERE url = $1
+WHERE canonical_url = sqlc.arg(canonical_url)::text
+   OR (canonical_url IS NULL AND url = sqlc.arg(url)::text)
+   OR id IN (SELECT feed_id FROM feed_url_history WHERE feed_url_history.canonical_url = sqlc.arg(canonical_url)::text)
+ORDER BY created_at
 LIMIT 1;
 
 -- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows ff
-WHERE ff.user_id = $1 AND ff.feed_id = (
-    SELECT id FROM feeds WHERE url = $2
-); 
\ No newline at end of file
+WHERE ff.user_id = $1 AND ff.feed_id = $2;
+
+-- name: SetFeedFollowKeepEpisodes :exec
+UPDATE feed_follows
+SET keep_episodes = $3,
+    updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2;
@@ -0,0 +1,24 @@
+-- name: AddFeedURLHistory :exec
+INSERT INTO feed_url_history (id, created_at, feed_id, url, canonical_url)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (canonical_url) DO UPDATE
+SET feed_id = EXCLUDED.feed_id,
+    url = EXCLUDED.url,
+    created_at = EXCLUDED.created_at;
+
+-- name: GetFeedURLHistory :many
+SELECT url, created_at
+FROM feed_url_history
+WHERE feed_id = $1
+ORDER BY created_at DESC;
+
+-- name: MoveFeedURLHistory :exec
+UPDATE feed_url_history
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id);
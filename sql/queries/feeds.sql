@@ -1,29 +1,195 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, canonical_url, proxy_url, request_headers)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8,
+    $9
 )
 RETURNING *;
 
+-- name: GetFeedURLs :many
+SELECT id, name, url, canonical_url
+FROM feeds
+ORDER BY created_at, id;
+
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2,
+    canonical_url = $3,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetFeedCanonicalURL :execrows
+-- Fills in the canonical URL of a feed added before URLs were canonicalized,
+-- unless another feed already has it; those are duplicates left for mergefeeds
+UPDATE feeds
+SET canonical_url = sqlc.arg(canonical_url)::text,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND canonical_url IS NULL
+  AND NOT EXISTS (SELECT 1 FROM feeds other WHERE other.canonical_url = sqlc.arg(canonical_url)::text);
+
+-- name: UpdateFeedSettings :exec
+UPDATE feeds
+SET name = $2,
+    proxy_url = $3,
+    request_headers = $4,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: MoveFeedFollows :exec
+-- Moves follows to another feed, skipping users who already follow it
+UPDATE feed_follows
+SET feed_id = sqlc.arg(to_feed_id),
+    updated_at = NOW()
+WHERE feed_id = sqlc.arg(from_feed_id)
+  AND user_id NOT IN (SELECT user_id FROM feed_follows WHERE feed_id = sqlc.arg(to_feed_id));
+
+-- name: MoveFeedPosts :exec
+-- Moves posts to another feed, skipping posts it already has
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id)
+  AND guid NOT IN (SELECT guid FROM posts WHERE feed_id = sqlc.arg(to_feed_id));
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
 -- name: GetFeeds :many
-SELECT f.id, f.name, f.url, u.name as user_name
+SELECT
+    f.id,
+    f.name,
+    f.url,
+    f.title,
+    f.site_url,
+    f.description,
+    f.language,
+    f.image_url,
+    f.generator,
+    f.last_success_at,
+    u.name as user_name
 FROM feeds f
 JOIN users u ON f.user_id = u.id
 ORDER BY f.name;
 
+-- name: GetFeedHealth :many
+SELECT
+    f.id,
+    f.name,
+    f.url,
+    f.created_at,
+    f.last_fetched_at,
+    f.last_success_at,
+    f.last_error,
+    f.last_error_kind,
+    f.last_status_code,
+    f.consecutive_failures,
+    f.disabled_at,
+    COUNT(p.id) AS item_count,
+    (COUNT(p.id) / GREATEST(EXTRACT(EPOCH FROM NOW() - f.created_at) / 86400, 1))::float8 AS items_per_day
+FROM feeds f
+LEFT JOIN posts p ON p.feed_id = f.id
+GROUP BY f.id
+ORDER BY f.name;
+
 -- name: MarkFeedFetched :exec
 UPDATE feeds
 SET last_fetched_at = NOW(),
     updated_at = NOW()
 WHERE id = $1;
 
--- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
-FROM feeds
-ORDER BY last_fetched_at NULLS FIRST
-LIMIT 1; 
\ No newline at end of file
+-- name: UpdateFeedSkipSchedule :exec
+UPDATE feeds
+SET skip_hours = $2,
+    skip_days = $3,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+    site_url = $3,
+    description = $4,
+    language = $5,
+    image_url = $6,
+    generator = $7,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkFeedFetchSucceeded :exec
+UPDATE feeds
+SET consecutive_failures = 0,
+    last_error = NULL,
+    last_error_kind = NULL,
+    last_status_code = sqlc.arg(last_status_code),
+    last_success_at = NOW(),
+    next_fetch_at = NOW() + make_interval(secs => sqlc.arg(next_fetch_in_seconds)::int),
+    fetch_interval_seconds = sqlc.arg(fetch_interval_seconds),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET consecutive_failures = sqlc.arg(consecutive_failures),
+    last_error = sqlc.arg(last_error),
+    last_status_code = sqlc.arg(last_status_code),
+    next_fetch_at = NOW() + make_interval(secs => sqlc.arg(next_fetch_in_seconds)::int),
+    disabled_at = CASE WHEN sqlc.arg(disabled)::bool THEN NOW() END,
+    last_error_kind = sqlc.arg(last_error_kind),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: ClearFeedResavePosts :exec
+UPDATE feeds
+SET resave_posts = FALSE
+WHERE id = $1;
+
+-- name: EnableFeed :exec
+UPDATE feeds
+SET consecutive_failures = 0,
+    next_fetch_at = NULL,
+    disabled_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET lease_owner = sqlc.arg(lease_owner)::text,
+    lease_expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE (lease_expires_at IS NULL OR lease_expires_at < NOW())
+      AND disabled_at IS NULL
+      AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY next_fetch_at NULLS FIRST, last_fetched_at NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RenewFeedLease :execrows
+-- Extends a lease the aggregator still holds; no rows means another aggregator took the feed
+UPDATE feeds
+SET lease_expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id = sqlc.arg(id) AND lease_owner = sqlc.arg(lease_owner)::text;
+
+-- name: ReleaseFeedLease :exec
+UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = sqlc.arg(id) AND lease_owner = sqlc.arg(lease_owner)::text; 
\ No newline at end of file
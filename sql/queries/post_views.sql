@@ -0,0 +1,21 @@
+-- name: MarkPostSeen :exec
+INSERT INTO post_views (user_id, post_id, seen_revision, seen_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET seen_revision = EXCLUDED.seen_revision,
+    seen_at = EXCLUDED.seen_at;
+
+-- name: MoveDuplicatePostViews :exec
+-- Copies the views of posts a feed shares with another feed onto the other feed's copies
+INSERT INTO post_views (user_id, post_id, seen_revision, seen_at)
+SELECT v.user_id, kept.id, LEAST(v.seen_revision, kept.revision), v.seen_at
+FROM post_views v
+JOIN posts dup ON dup.id = v.post_id
+JOIN posts kept ON kept.guid = dup.guid AND kept.feed_id = sqlc.arg(to_feed_id)
+WHERE dup.feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
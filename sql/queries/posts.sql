@@ -1,5 +1,17 @@
+-- name: AdoptMigratedPost :exec
+-- Gives a post stored before guids were tracked the guid of the item it came from, so the item
+-- updates it instead of being stored again. Such posts carry the fallback guid of their own link
+-- and title (see 012_post_guids).
+UPDATE posts
+SET guid = sqlc.arg(guid)
+WHERE feed_id = sqlc.arg(feed_id)
+  AND url = sqlc.arg(url)
+  AND guid = 'sha256:' || encode(sha256(convert_to(url || E'\n' || title, 'UTF8')), 'hex')
+  AND guid <> sqlc.arg(guid)
+  AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid));
+
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content, episode)
 VALUES (
     $1,
     $2,
@@ -8,9 +20,56 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
 )
-RETURNING *;
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    episode = EXCLUDED.episode,
+    published_at = EXCLUDED.published_at,
+    content_hash = EXCLUDED.content_hash,
+    -- Filling in content for a post stored before it was kept is not an edit
+    revision = CASE
+        WHEN posts.content IS NULL
+            AND posts.title = EXCLUDED.title
+            AND posts.description IS NOT DISTINCT FROM EXCLUDED.description
+        THEN posts.revision
+        ELSE posts.revision + 1
+    END,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, revision;
+
+-- name: GetPostForUser :one
+-- Gets a post from one of the feeds the user follows
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.content,
+    p.published_at,
+    p.feed_id,
+    p.revision,
+    f.name AS feed_name
+FROM posts p
+JOIN feeds f ON p.feed_id = f.id
+JOIN feed_follows ff ON f.id = ff.feed_id
+WHERE p.id = sqlc.arg(id)
+  AND ff.user_id = sqlc.arg(user_id);
+
+-- name: GetPostIDByGUID :one
+SELECT id
+FROM posts
+WHERE feed_id = $1 AND guid = $2;
 
 -- name: GetPostsForUser :many
 SELECT 
@@ -22,10 +81,26 @@ SELECT
     p.description,
     p.published_at,
     p.feed_id,
-    f.name AS feed_name
+    p.revision,
+    p.episode,
+    f.name AS feed_name,
+    pv.seen_revision
 FROM posts p
 JOIN feeds f ON p.feed_id = f.id
 JOIN feed_follows ff ON f.id = ff.feed_id
-WHERE ff.user_id = $1
+LEFT JOIN post_views pv ON pv.post_id = p.id AND pv.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(author)::text IS NULL OR EXISTS (
+      SELECT 1
+      FROM post_authors pa
+      JOIN authors a ON pa.author_id = a.id
+      WHERE pa.post_id = p.id AND LOWER(a.name) = LOWER(sqlc.narg(author)::text)
+  ))
+  AND (sqlc.narg(category)::text IS NULL OR EXISTS (
+      SELECT 1
+      FROM post_categories pc
+      JOIN categories c ON pc.category_id = c.id
+      WHERE pc.post_id = p.id AND LOWER(c.name) = LOWER(sqlc.narg(category)::text)
+  ))
 ORDER BY p.published_at DESC
-LIMIT $2; 
\ No newline at end of file
+LIMIT sqlc.arg('limit')::int; 
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN last_error TEXT;
+ALTER TABLE feeds ADD COLUMN last_success_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN next_fetch_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN disabled_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN disabled_at;
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
+ALTER TABLE feeds DROP COLUMN last_success_at;
+ALTER TABLE feeds DROP COLUMN last_error;
+ALTER TABLE feeds DROP COLUMN consecutive_failures;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN skip_hours INTEGER[] NOT NULL DEFAULT '{}';
+ALTER TABLE feeds ADD COLUMN skip_days TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN skip_days;
+ALTER TABLE feeds DROP COLUMN skip_hours;
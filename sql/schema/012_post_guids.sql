@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN guid TEXT;
+-- The original guids are unknown, so use the fallback postGUID computes for items without one:
+-- "sha256:" and the hex SHA-256 of the link and title. AdoptMigratedPost moves these rows to the
+-- real guid on the first fetch of their feed after 025_feed_resave_posts.
+UPDATE posts SET guid = 'sha256:' || encode(sha256(convert_to(url || E'\n' || title, 'UTF8')), 'hex');
+ALTER TABLE posts ALTER COLUMN guid SET NOT NULL;
+ALTER TABLE posts DROP CONSTRAINT posts_url_key;
+ALTER TABLE posts ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+
+-- +goose Down
+ALTER TABLE posts DROP CONSTRAINT posts_feed_id_guid_key;
+DELETE FROM posts a USING posts b WHERE a.url = b.url AND a.ctid > b.ctid;
+ALTER TABLE posts ADD CONSTRAINT posts_url_key UNIQUE (url);
+ALTER TABLE posts DROP COLUMN guid;
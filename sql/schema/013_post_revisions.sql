@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content_hash TEXT;
+UPDATE posts SET content_hash = encode(sha256(convert_to(title || E'\n' || COALESCE(description, ''), 'UTF8')), 'hex');
+ALTER TABLE posts ALTER COLUMN content_hash SET NOT NULL;
+ALTER TABLE posts ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE post_views (
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    seen_revision INTEGER NOT NULL,
+    seen_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE post_views;
+ALTER TABLE posts DROP COLUMN revision;
+ALTER TABLE posts DROP COLUMN content_hash;
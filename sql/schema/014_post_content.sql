@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content TEXT;
+-- The content hash now covers the content too, so recompute it the way contentHash does
+UPDATE posts SET content_hash = encode(sha256(convert_to(title || E'\n' || COALESCE(description, '') || E'\n' || COALESCE(content, ''), 'UTF8')), 'hex');
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN content;
+UPDATE posts SET content_hash = encode(sha256(convert_to(title || E'\n' || COALESCE(description, ''), 'UTF8')), 'hex');
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE enclosures (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT,
+    length BIGINT,
+    duration_seconds INTEGER,
+    UNIQUE(post_id, url),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+ALTER TABLE posts ADD COLUMN episode INTEGER;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN episode;
+DROP TABLE enclosures;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE feed_follows ADD COLUMN keep_episodes INTEGER;
+
+CREATE TABLE downloads (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    enclosure_id UUID NOT NULL,
+    status TEXT NOT NULL,
+    file_path TEXT,
+    bytes_downloaded BIGINT NOT NULL DEFAULT 0,
+    total_bytes BIGINT,
+    last_error TEXT,
+    UNIQUE(user_id, enclosure_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (enclosure_id) REFERENCES enclosures(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE downloads;
+ALTER TABLE feed_follows DROP COLUMN keep_episodes;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Scheme-less normalized form of the URL used to detect duplicate feeds.
+-- Existing feeds are filled in by the first agg run, or by the mergefeeds command.
+ALTER TABLE feeds ADD COLUMN canonical_url TEXT UNIQUE;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN canonical_url;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Previous URLs of feeds that moved with a permanent redirect, so they can still be looked up by them
+CREATE TABLE feed_url_history (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    feed_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    canonical_url TEXT NOT NULL UNIQUE,
+    FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_url_history;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- What kind of failure last_error is: network, http or parse
+ALTER TABLE feeds ADD COLUMN last_error_kind TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN last_error_kind;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Proxy used for this feed instead of the global one (http://, https:// or socks5:// URL)
+ALTER TABLE feeds ADD COLUMN proxy_url TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN proxy_url;
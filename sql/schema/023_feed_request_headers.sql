@@ -0,0 +1,6 @@
+-- +goose Up
+-- Extra request headers such as Authorization, as JSON encrypted with the configured secret key
+ALTER TABLE feeds ADD COLUMN request_headers BYTEA;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN request_headers;
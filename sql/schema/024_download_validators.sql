@@ -0,0 +1,8 @@
+-- +goose Up
+-- Validators of the file being downloaded, sent as If-Range so a partial file is only resumed if it has not changed
+ALTER TABLE downloads ADD COLUMN etag TEXT;
+ALTER TABLE downloads ADD COLUMN last_modified TEXT;
+
+-- +goose Down
+ALTER TABLE downloads DROP COLUMN last_modified;
+ALTER TABLE downloads DROP COLUMN etag;
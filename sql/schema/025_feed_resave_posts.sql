@@ -0,0 +1,10 @@
+-- +goose Up
+-- Set for feeds with posts stored by earlier versions. Their next fetch saves every item in full,
+-- even posts that have not changed, to fill in what those versions did not store: the guid of
+-- posts stored before guids were tracked (see 012_post_guids), and the enclosures, authors and
+-- categories of posts stored before 015_enclosures and 017_authors_categories. The flag is cleared afterwards.
+ALTER TABLE feeds ADD COLUMN resave_posts BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE feeds SET resave_posts = TRUE WHERE id IN (SELECT feed_id FROM posts);
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN resave_posts;